@@ -0,0 +1,69 @@
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestCredential struct {
+	User     string `redact:"snapshot"`
+	Password string
+}
+
+type TestArrayStruct struct {
+	Names       [2]string
+	Hints       [2]*string `redact:"snapshot"`
+	Credentials [2]*TestCredential
+	Values      [2]TestCredential
+	Key         [8]byte
+	Digest      [4]byte `redact:"snapshot"`
+	Scores      [3]int
+	Matrix      [2][2]string `redact:"last4"`
+}
+
+func TestArrayRedaction(t *testing.T) {
+	t.Run("Should redact array elements like slice elements", func(t *testing.T) {
+		hint := snapshotVal
+		tStruct := &TestArrayStruct{
+			Names:       [2]string{nonSnapshotVal, nonSnapshotVal},
+			Hints:       [2]*string{&hint, nil},
+			Credentials: [2]*TestCredential{{User: "gopher", Password: "hunter2"}, nil},
+			Values:      [2]TestCredential{{User: "gopher", Password: "hunter2"}},
+			Key:         [8]byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Digest:      [4]byte{1, 2, 3, 4},
+			Scores:      [3]int{1, 2, 3},
+			Matrix:      [2][2]string{{"4111111111111111", "12"}},
+		}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, [2]string{redact.RedactStrConst, redact.RedactStrConst}, tStruct.Names, "should redact string arrays")
+		assert.Equal(t, snapshotVal, *tStruct.Hints[0], "should contain snapshot value")
+		assert.Nil(t, tStruct.Hints[1], "should keep nil pointers")
+		assert.Equal(t, "gopher", tStruct.Credentials[0].User, "should contain snapshot value")
+		assert.Equal(t, redact.RedactStrConst, tStruct.Credentials[0].Password, "should redact structs behind pointers")
+		assert.Equal(t, redact.RedactStrConst, tStruct.Values[0].Password, "should redact struct values")
+		assert.Equal(t, [8]byte{'N', 'O', 'N', 'S', 'N', 'A', 'P', 'S'}, tStruct.Key, "should fit the placeholder into byte arrays")
+		assert.Equal(t, [4]byte{1, 2, 3, 4}, tStruct.Digest, "should contain snapshot value")
+		assert.Equal(t, [3]int{}, tStruct.Scores, "should redact number arrays")
+		assert.Equal(t, [2][2]string{{"************1111", "**"}, {"", ""}}, tStruct.Matrix, "should redact nested arrays")
+	})
+
+	t.Run("Should redact arrays in copies", func(t *testing.T) {
+		tStruct := TestArrayStruct{
+			Credentials: [2]*TestCredential{{Password: "hunter2"}},
+			Names:       [2]string{nonSnapshotVal},
+		}
+
+		redacted, err := redact.Redacted(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, redact.RedactStrConst, redacted.Credentials[0].Password, "should redact the copy")
+		assert.Equal(t, redact.RedactStrConst, redacted.Names[0], "should redact the copy")
+		assert.Equal(t, "hunter2", tStruct.Credentials[0].Password, "should not modify the original")
+		assert.Equal(t, nonSnapshotVal, tStruct.Names[0], "should not modify the original")
+	})
+}
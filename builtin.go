@@ -0,0 +1,252 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// builtinRedactors are registered on every Redactor created with New.
+//
+//	mask                 replaces every character with '*'
+//	mask(first=2,last=4) keeps the first 2 and last 4 characters
+//	mask(keep=4)         keeps the last 4 characters, from=start keeps the first
+//	last4                keeps the last 4 characters, like mask(keep=4)
+//	fixed(8)             replaces the value with 8 '*', hiding its length
+//	sha256, hash         the hex encoded SHA-256 of the value
+//	hmac                 the hex encoded HMAC-SHA256 of the value, keyed with
+//	                     the key given to WithHashKey
+//	truncate(8)          keeps the first 8 characters
+//	email                masks the local part and keeps the domain
+//	empty                replaces the value with the empty string
+//	len                  replaces the value with its length in characters
+//
+// mask, last4, fixed and email take a char argument to use instead of '*'.
+//
+// sha256 and hash are not keyed: anyone can hash guesses and compare, which
+// finds values with few possibilities, such as phone numbers, birth dates or
+// short IDs, within seconds. Use hmac with a secret key for such values.
+var builtinRedactors = map[string]RedactorFunc{
+	"mask":     maskRedactor,
+	"last4":    last4Redactor,
+	"fixed":    fixedRedactor,
+	"sha256":   sha256Redactor,
+	"hash":     sha256Redactor,
+	"hmac":     hmacRedactor(nil),
+	"truncate": truncateRedactor,
+	"email":    emailRedactor,
+	"empty":    emptyRedactor,
+	"len":      lenRedactor,
+}
+
+func maskRedactor(input string, args Args) (string, error) {
+	char, err := maskChar(args)
+	if err != nil {
+		return "", err
+	}
+	first, err := args.Int("first", -1, 0)
+	if err != nil {
+		return "", err
+	}
+	last, err := args.Int("last", -1, 0)
+	if err != nil {
+		return "", err
+	}
+	keep, err := args.Int("keep", 0, 0)
+	if err != nil {
+		return "", err
+	}
+	switch from := args.String("from", -1, "end"); from {
+	case "start":
+		first += keep
+	case "end":
+		last += keep
+	default:
+		return "", errors.New("argument from: must be start or end")
+	}
+	if first < 0 || last < 0 {
+		return "", errors.New("mask can not keep a negative number of characters")
+	}
+
+	return mask(input, first, last, char), nil
+}
+
+func last4Redactor(input string, args Args) (string, error) {
+	char, err := maskChar(args)
+	if err != nil {
+		return "", err
+	}
+	return mask(input, 0, 4, char), nil
+}
+
+func fixedRedactor(_ string, args Args) (string, error) {
+	char, err := maskChar(args)
+	if err != nil {
+		return "", err
+	}
+	n, err := args.Int("len", 0, 8)
+	if err != nil {
+		return "", err
+	}
+	if n < 0 {
+		return "", errors.New("fixed needs a length of zero or more")
+	}
+	return strings.Repeat(string(char), n), nil
+}
+
+func sha256Redactor(input string, _ Args) (string, error) {
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// hmacRedactor returns the hmac redactor for key. Without a key it fails, so
+// values are not hashed unkeyed by mistake.
+func hmacRedactor(key []byte) RedactorFunc {
+	return func(input string, _ Args) (string, error) {
+		if len(key) == 0 {
+			return "", errors.New("hmac needs a key, see WithHashKey")
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(input))
+		return hex.EncodeToString(mac.Sum(nil)), nil
+	}
+}
+
+func truncateRedactor(input string, args Args) (string, error) {
+	n, err := args.Int("len", 0, -1)
+	if err != nil {
+		return "", err
+	}
+	if n < 0 {
+		return "", errors.New("truncate needs a length of zero or more")
+	}
+
+	runes := []rune(input)
+	if len(runes) <= n {
+		return input, nil
+	}
+	return string(runes[:n]), nil
+}
+
+func emailRedactor(input string, args Args) (string, error) {
+	char, err := maskChar(args)
+	if err != nil {
+		return "", err
+	}
+	at := strings.LastIndexByte(input, '@')
+	if at < 0 {
+		return mask(input, 0, 0, char), nil
+	}
+	return mask(input[:at], 0, 0, char) + input[at:], nil
+}
+
+func emptyRedactor(string, Args) (string, error) {
+	return "", nil
+}
+
+func lenRedactor(input string, _ Args) (string, error) {
+	return strconv.Itoa(utf8.RuneCountInString(input)), nil
+}
+
+// maskChar returns the char argument, '*' by default.
+func maskChar(args Args) (rune, error) {
+	char := args.String("char", -1, "*")
+	if utf8.RuneCountInString(char) != 1 {
+		return 0, errors.New("argument char: must be a single character")
+	}
+	r, _ := utf8.DecodeRuneInString(char)
+	return r, nil
+}
+
+// mask replaces all but the first and last characters of input with char. If
+// first and last cover the whole input, all of it is masked so a short value
+// is never returned in clear text.
+func mask(input string, first, last int, char rune) string {
+	runes := []rune(input)
+	if first+last >= len(runes) {
+		first, last = 0, 0
+	}
+	for i := first; i < len(runes)-last; i++ {
+		runes[i] = char
+	}
+	return string(runes)
+}
+
+// numberRedactors are the rules that apply to numeric fields. Numbers tagged
+// with any other rule but "snapshot" are set to the numeric placeholder.
+//
+//	round      rounds to the nearest integer
+//	round(100) rounds to the nearest multiple of 100, round(0.01) to cents
+var numberRedactors = map[string]func(reflect.Value, Args) error{
+	"round": roundNumber,
+}
+
+func roundNumber(val reflect.Value, args Args) error {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		to, err := args.Int("to", 0, 1)
+		if err != nil {
+			return err
+		}
+		if to <= 0 {
+			return errors.New("round needs a positive integer for integer fields")
+		}
+		rounded, ok := roundInt(val.Int(), int64(to))
+		if !ok || val.OverflowInt(rounded) {
+			return errors.New("rounded value overflows")
+		}
+		val.SetInt(rounded)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		to, err := args.Int("to", 0, 1)
+		if err != nil {
+			return err
+		}
+		if to <= 0 {
+			return errors.New("round needs a positive integer for integer fields")
+		}
+		n, step := val.Uint(), uint64(to)
+		rounded := n / step * step
+		if n%step >= step-n%step {
+			rounded += step
+		}
+		if rounded < n-n%step || val.OverflowUint(rounded) {
+			return errors.New("rounded value overflows")
+		}
+		val.SetUint(rounded)
+	case reflect.Float32, reflect.Float64:
+		to, err := args.Float("to", 0, 1)
+		if err != nil {
+			return err
+		}
+		if to <= 0 {
+			return errors.New("round needs a positive number")
+		}
+		val.SetFloat(math.Round(val.Float()/to) * to)
+	}
+	return nil
+}
+
+// roundInt rounds n to the nearest multiple of step, halves away from zero. It
+// reports false if the result overflows an int64.
+func roundInt(n, step int64) (int64, bool) {
+	rem := n % step
+	rounded := n - rem
+	if rem < 0 {
+		rem = -rem
+	}
+	if rem >= step-rem {
+		if n < 0 {
+			rounded -= step
+			return rounded, rounded < 0
+		}
+		rounded += step
+		return rounded, rounded > 0
+	}
+	return rounded, true
+}
@@ -0,0 +1,84 @@
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestBuiltinStruct struct {
+	Short     string `redact:"mask(first=2,last=2)"`
+	Char      string `redact:"mask(keep=2,from=start,char=#)"`
+	Unicode   string `redact:"mask(keep=1)"`
+	NoDomain  string `redact:"email(char=x)"`
+	Truncated string `redact:"truncate(len=3)"`
+	Hash      string `redact:"hash"`
+}
+
+type TestBuiltinErrors struct {
+	NoLength string `redact:"truncate"`
+	BadFrom  string `redact:"mask(keep=2,from=middle)"`
+	BadChar  string `redact:"fixed(char=ab)"`
+}
+
+func TestBuiltinRedactors(t *testing.T) {
+	t.Run("Should be registered on new redactors", func(t *testing.T) {
+		names := redact.New().Redactors()
+		for _, name := range []string{"mask", "last4", "fixed", "sha256", "hash", "hmac", "truncate", "email", "empty", "len"} {
+			assert.Contains(t, names, name, "should register built-in redactor")
+		}
+	})
+
+	t.Run("Should apply arguments", func(t *testing.T) {
+		tStruct := &TestBuiltinStruct{
+			Short:     "abc",
+			Char:      "secret",
+			Unicode:   "géographie",
+			NoDomain:  "not-an-email",
+			Truncated: "héllo",
+			Hash:      "",
+		}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, "***", tStruct.Short, "should mask values shorter than the kept characters")
+		assert.Equal(t, "se####", tStruct.Char, "should keep from the start with the given char")
+		assert.Equal(t, "*********e", tStruct.Unicode, "should count characters instead of bytes")
+		assert.Equal(t, "xxxxxxxxxxxx", tStruct.NoDomain, "should mask values without a domain")
+		assert.Equal(t, "hél", tStruct.Truncated, "should truncate characters")
+		assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", tStruct.Hash, "should hash empty values")
+	})
+
+	t.Run("Should hash with a key", func(t *testing.T) {
+		type TestBuiltinHMAC struct {
+			Phone string `redact:"hmac"`
+		}
+
+		tStruct := &TestBuiltinHMAC{Phone: "555-0100"}
+		err := redact.New(redact.WithHashKey([]byte("key"))).Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+		assert.Equal(t, "7ba9926db6a9462e4bd7c154a1584fb5af3ab27131c971cd56a233916e5f5a9c", tStruct.Phone, "should write the HMAC-SHA256")
+
+		tStruct = &TestBuiltinHMAC{Phone: "555-0100"}
+		err = redact.Snapshot(tStruct)
+		assert.Error(t, err, "should fail without a key")
+		assert.Equal(t, redact.RedactStrConst, tStruct.Phone, "should not hash without a key")
+	})
+
+	t.Run("Should fail on invalid arguments", func(t *testing.T) {
+		tStruct := &TestBuiltinErrors{
+			NoLength: nonSnapshotVal,
+			BadFrom:  nonSnapshotVal,
+			BadChar:  nonSnapshotVal,
+		}
+
+		err := redact.Snapshot(tStruct)
+		assert.Error(t, err, "should report invalid arguments")
+
+		assert.Equal(t, redact.RedactStrConst, tStruct.NoLength, "should fall back to the placeholder")
+		assert.Equal(t, redact.RedactStrConst, tStruct.BadFrom, "should fall back to the placeholder")
+		assert.Equal(t, redact.RedactStrConst, tStruct.BadChar, "should fall back to the placeholder")
+	})
+}
@@ -0,0 +1,71 @@
+package redact_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestBytesStruct struct {
+	Password []byte
+	Token    []uint8 `redact:"last4"`
+	Payload  json.RawMessage
+	Public   []byte          `redact:"snapshot"`
+	Raw      json.RawMessage `redact:"snapshot"`
+	Keys     [][]byte
+	Missing  []byte
+}
+
+func TestBytesRedaction(t *testing.T) {
+	t.Run("Should redact byte slices like strings", func(t *testing.T) {
+		tStruct := &TestBytesStruct{
+			Password: []byte("hunter2"),
+			Token:    []uint8("tok_123456"),
+			Payload:  json.RawMessage(`{"card":"4111111111111111"}`),
+			Public:   []byte(snapshotVal),
+			Raw:      json.RawMessage(`{"id":1}`),
+			Keys:     [][]byte{[]byte("key")},
+		}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, []byte(redact.RedactStrConst), tStruct.Password, "should redact non snapshot bytes")
+		assert.Equal(t, []byte("******3456"), tStruct.Token, "should apply redactors to bytes")
+		assert.Equal(t, json.RawMessage(`"NONSNAPSHOT"`), tStruct.Payload, "should keep raw messages valid JSON")
+		assert.Equal(t, []byte(snapshotVal), tStruct.Public, "should contain snapshot value")
+		assert.Equal(t, json.RawMessage(`{"id":1}`), tStruct.Raw, "should contain snapshot value")
+		assert.Equal(t, []byte(redact.RedactStrConst), tStruct.Keys[0], "should redact nested byte slices")
+		assert.Nil(t, tStruct.Missing, "should keep nil byte slices")
+		assert.True(t, json.Valid(mustMarshal(t, tStruct)), "should marshal to valid JSON")
+	})
+
+	t.Run("Should keep the original backing array by default", func(t *testing.T) {
+		password := []byte("hunter2")
+		tStruct := &TestBytesStruct{Password: password}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+		assert.Equal(t, []byte("hunter2"), password, "should not modify the backing array")
+	})
+
+	t.Run("Should zero the original backing array with WithWipeBytes", func(t *testing.T) {
+		password := []byte("hunter2")
+		tStruct := &TestBytesStruct{Password: password}
+
+		err := redact.New(redact.WithWipeBytes()).Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+		assert.Equal(t, make([]byte, 7), password, "should zero the backing array")
+		assert.Equal(t, []byte(redact.RedactStrConst), tStruct.Password, "should redact non snapshot bytes")
+	})
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	assert.NoError(t, err, "should marshal value")
+	return data
+}
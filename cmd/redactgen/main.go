@@ -0,0 +1,399 @@
+// Redactgen generates methods that redact and copy struct types field by
+// field instead of walking them with reflection.
+//
+// Given the struct types User and Address, running
+//
+//	redactgen -type User,Address
+//
+// in their package writes user_redact.go, which gives *User and *Address the
+// methods
+//
+//	func (v *User) RedactSnapshot() error
+//	func (v *User) RedactedCopy() (*User, error)
+//	func (v *User) RedactWith(g *redact.Gen, rule redact.Rule)
+//	func (v *User) CloneWith(c *redact.Clone) interface{}
+//	func (v *User) CloneInto(c *redact.Clone, cp *User)
+//
+// RedactSnapshot and RedactedCopy behave like redact.Snapshot and
+// redact.Redacted. RedactWith redacts the fields one by one as the `redact`
+// tags say and CloneInto deep copies them; redact.Snapshot and redact.Copy
+// call them instead of walking the value with reflection. Fields that are
+// strings, bools, numbers, other generated types or pointers to these are
+// handled by the generated code, every other field, such as a slice or a map,
+// is handed back to the reflection walker. Number rules that compute a value,
+// such as round, are applied by the same code as for the reflection walker.
+//
+// Redactgen is meant to be run by go generate:
+//
+//	//go:generate go run github.com/samkreter/redact/cmd/redactgen -type User,Address
+//
+// The generated code needs to be regenerated whenever the fields or tags of
+// the types change.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samkreter/redact"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma separated list of struct type names; must be set")
+	output    = flag.String("output", "", "output file name; default <dir>/<type>_redact.go")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of redactgen:\n")
+	fmt.Fprintf(os.Stderr, "\tredactgen -type T[,T...] [directory]\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("redactgen: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *typeNames == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	types := strings.Split(*typeNames, ",")
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, strings.ToLower(types[0])+"_redact.go")
+	}
+
+	src, err := generate(dir, types, filepath.Base(outputName))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(outputName, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the generated source for the struct types typeNames of
+// the package in dir. The file skip, usually an earlier output, is not read.
+func generate(dir string, typeNames []string, skip string) ([]byte, error) {
+	pkg, err := loadPackage(dir, skip)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{pkg: pkg, generated: map[*types.TypeName]bool{}}
+	for _, name := range typeNames {
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("type %s not found in package %s", name, pkg.Name())
+		}
+		if err := checkType(obj); err != nil {
+			return nil, err
+		}
+		g.generated[obj] = true
+		g.types = append(g.types, obj)
+	}
+
+	g.printf("// Code generated by \"redactgen -type %s\"; DO NOT EDIT.\n\n", strings.Join(typeNames, ","))
+	g.printf("package %s\n\n", pkg.Name())
+	g.printf("import \"github.com/samkreter/redact\"\n")
+	for _, obj := range g.types {
+		if err := g.generateType(obj); err != nil {
+			return nil, err
+		}
+	}
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// loadPackage parses and type-checks the non-test Go files in dir. Imports
+// are type-checked from source; if that fails the affected fields have an
+// invalid type and are left to the reflection walker.
+func loadPackage(dir, skip string) (*types.Package, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != skip
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("found %d packages in %s, want 1", len(pkgs), dir)
+	}
+
+	var files []*ast.File
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return fset.File(files[i].Pos()).Name() < fset.File(files[j].Pos()).Name()
+	})
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+	pkg, _ := conf.Check(files[0].Name.Name, fset, files, nil)
+	if pkg == nil {
+		return nil, errors.New("type-checking failed")
+	}
+	return pkg, nil
+}
+
+// checkType returns an error if obj is not a struct type redactgen can
+// generate methods for.
+func checkType(obj *types.TypeName) error {
+	named, ok := obj.Type().(*types.Named)
+	if !ok || obj.IsAlias() {
+		return fmt.Errorf("%s is not a defined type", obj.Name())
+	}
+	if named.TypeParams().Len() > 0 {
+		return fmt.Errorf("%s: generic types are not supported", obj.Name())
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return fmt.Errorf("%s is not a struct type", obj.Name())
+	}
+	if redactsItself(named) {
+		return fmt.Errorf("%s already redacts itself with a Redact or RedactInPlace method", obj.Name())
+	}
+	return nil
+}
+
+type generator struct {
+	pkg       *types.Package
+	types     []*types.TypeName
+	generated map[*types.TypeName]bool
+	buf       bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// generateType writes the rules and methods of the struct type obj.
+func (g *generator) generateType(obj *types.TypeName) error {
+	name := obj.Name()
+	st := obj.Type().Underlying().(*types.Struct)
+	rules := "redactRules" + strings.ToUpper(name[:1]) + name[1:]
+
+	type field struct {
+		name, kind string
+		rule       int
+		// clone is set if the field holds references the copy must not share
+		clone bool
+	}
+	var (
+		fields []field
+		tags   []string
+	)
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		// the walker leaves unexported fields alone when it uses generated
+		// methods, apart from embedded structs whose fields are promoted
+		if !v.Exported() && !isPromoted(v) {
+			continue
+		}
+
+		tag := reflect.StructTag(st.Tag(i)).Get("redact")
+		if _, err := redact.ParseRule(tag); err != nil {
+			return fmt.Errorf("%s.%s: %w", name, v.Name(), err)
+		}
+		fields = append(fields, field{
+			name:  v.Name(),
+			kind:  g.fieldKind(v.Type()),
+			rule:  len(tags),
+			clone: hasReferences(v.Type(), map[types.Type]bool{}),
+		})
+		tags = append(tags, tag)
+	}
+
+	g.printf("\nvar %s = [...]redact.Rule{\n", rules)
+	for _, tag := range tags {
+		if tag == "" {
+			g.printf("\t{},\n")
+			continue
+		}
+		g.printf("\tredact.MustParseRule(%s),\n", strconv.Quote(tag))
+	}
+	g.printf("}\n")
+
+	g.printf(`
+// RedactSnapshot redacts v in place like redact.Snapshot(v).
+func (v *%[1]s) RedactSnapshot() error {
+	return redact.SnapshotGenerated(v)
+}
+
+// RedactedCopy returns a redacted deep copy of v like redact.Redacted(v).
+func (v *%[1]s) RedactedCopy() (*%[1]s, error) {
+	return redact.CopyGenerated(v)
+}
+
+// CloneWith returns a deep copy of v. It is called by redact.Copy.
+func (v *%[1]s) CloneWith(c *redact.Clone) interface{} {
+	return redact.ClonePtr(c, v)
+}
+
+// RedactWith redacts the fields of v, which is held by a field tagged with
+// rule. It is called by the redact walker.
+func (v *%[1]s) RedactWith(g *redact.Gen, rule redact.Rule) {
+`, name)
+	for _, f := range fields {
+		rule := fmt.Sprintf("g.Inherit(%s[%d], rule)", rules, f.rule)
+		g.printf("\tg.Field(%q)\n", f.name)
+		switch f.kind {
+		case "string":
+			g.printf("\tredact.String(g, &v.%s, %s)\n", f.name, rule)
+		case "bool":
+			g.printf("\tredact.Bool(g, &v.%s, %s)\n", f.name, rule)
+		case "number":
+			g.printf("\tredact.Number(g, &v.%s, %s)\n", f.name, rule)
+		case "generated":
+			g.printf("\tv.%s.RedactWith(g, %s)\n", f.name, rule)
+		case "*string", "*bool", "*number":
+			g.printf("\tif r := %s; redact.Enter(g, v.%s, r) {\n", rule, f.name)
+			g.printf("\t\tredact.%s(g, v.%s, r)\n", strings.Title(f.kind[1:]), f.name)
+			g.printf("\t}\n")
+		case "*generated":
+			g.printf("\tif r := %s; redact.Enter(g, v.%s, r) {\n", rule, f.name)
+			g.printf("\t\tv.%s.RedactWith(g, r)\n", f.name)
+			g.printf("\t}\n")
+		default:
+			g.printf("\tg.Value(&v.%s, %s)\n", f.name, rule)
+		}
+		g.printf("\tg.Pop()\n")
+	}
+	g.printf("}\n")
+
+	g.printf(`
+// CloneInto sets *cp to a deep copy of v. It is called by redact.Copy.
+func (v *%s) CloneInto(c *redact.Clone, cp *%[1]s) {
+	*cp = *v
+`, name)
+	for _, f := range fields {
+		switch {
+		case !f.clone:
+		case f.kind == "generated":
+			g.printf("\tv.%s.CloneInto(c, &cp.%[1]s)\n", f.name)
+		case f.kind == "*generated":
+			g.printf("\tcp.%s = redact.ClonePtr(c, v.%[1]s)\n", f.name)
+		case strings.HasPrefix(f.kind, "*"):
+			g.printf("\tcp.%s = redact.ClonePtrTo(c, v.%[1]s)\n", f.name)
+		default:
+			g.printf("\tc.Value(&cp.%s)\n", f.name)
+		}
+	}
+	g.printf("}\n")
+	return nil
+}
+
+// fieldKind returns how the generated code redacts a field of type t:
+// "string", "bool", "number", "generated" for a struct type with generated
+// methods, one of these prefixed with "*" for a pointer to it, or "value" to
+// hand it to the reflection walker.
+func (g *generator) fieldKind(t types.Type) string {
+	if ptr, ok := t.(*types.Pointer); ok {
+		if kind := g.fieldKind(ptr.Elem()); kind != "value" && !redactsItself(t) {
+			return "*" + kind
+		}
+		return "value"
+	}
+	if redactsItself(t) {
+		return "value"
+	}
+	if named, ok := t.(*types.Named); ok && g.generated[named.Obj()] {
+		return "generated"
+	}
+
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "value"
+	}
+	switch info := basic.Info(); {
+	case info&types.IsString != 0:
+		return "string"
+	case info&types.IsBoolean != 0:
+		return "bool"
+	case info&(types.IsInteger|types.IsFloat) != 0 && info&types.IsUntyped == 0:
+		return "number"
+	default:
+		return "value"
+	}
+}
+
+// isPromoted reports whether v is an embedded unexported struct, or pointer
+// to one, whose exported fields are promoted and redacted like the others.
+func isPromoted(v *types.Var) bool {
+	t := v.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	_, ok := t.Underlying().(*types.Struct)
+	return v.Embedded() && !v.Exported() && ok
+}
+
+// hasReferences reports whether a value of type t holds pointers, slices,
+// maps or interfaces in itself or its exported fields, which a deep copy
+// clones. Unexported fields are shared with the original, as redact.Copy
+// shares them.
+func hasReferences(t types.Type, seen map[types.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch u := t.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Interface:
+		return true
+	case *types.Array:
+		return hasReferences(u.Elem(), seen)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if field := u.Field(i); (field.Exported() || isPromoted(field)) && hasReferences(field.Type(), seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactsItself reports whether t or *t has a Redact or RedactInPlace method,
+// which the reflection walker calls.
+func redactsItself(t types.Type) bool {
+	for _, typ := range []types.Type{t, types.NewPointer(t)} {
+		mset := types.NewMethodSet(typ)
+		for _, name := range []string{"Redact", "RedactInPlace"} {
+			if mset.Lookup(nil, name) != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join("..", "..", "internal", "gentest")
+
+	t.Run("Should match the generated code in internal/gentest", func(t *testing.T) {
+		want, err := os.ReadFile(filepath.Join(dir, "user_redact.go"))
+		assert.NoError(t, err, "should read the generated code")
+
+		got, err := generate(dir, []string{"User", "Address", "Account"}, "user_redact.go")
+		assert.NoError(t, err, "should not fail to generate")
+		assert.Equal(t, string(want), string(got), "should be up to date, run go generate ./...")
+	})
+
+	t.Run("Should reject types it can not generate methods for", func(t *testing.T) {
+		_, err := generate(dir, []string{"Missing"}, "user_redact.go")
+		assert.Error(t, err, "should reject unknown types")
+		assert.Contains(t, err.Error(), "not found", "should reject unknown types")
+
+		_, err = generate(dir, []string{"Token"}, "user_redact.go")
+		assert.Error(t, err, "should reject other types")
+		assert.Contains(t, err.Error(), "not a struct type", "should reject other types")
+	})
+}
@@ -0,0 +1,175 @@
+package redact
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Copy returns a redacted deep copy of iface using the default Redactor,
+// leaving iface untouched.
+func Copy(iface interface{}) (interface{}, error) {
+	return defaultRedactor.Copy(iface)
+}
+
+// Copy returns a redacted deep copy of iface, leaving iface untouched.
+// Pointers, slices and maps are cloned so that redacting the copy can never
+// overwrite data shared with the original. Values that are aliased in the
+// original (two pointers to the same string, a slice reachable twice) are
+// aliased in the copy as well, and cyclic references are cloned as cycles.
+func (r *Redactor) Copy(iface interface{}) (interface{}, error) {
+	cp, err := r.copy(iface)
+	if err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// copy is like Copy, but also returns the copy if there were errors. Values
+// that failed to redact hold the placeholder, so the copy is safe to show.
+func (r *Redactor) copy(iface interface{}) (interface{}, error) {
+	return r.copyWith(iface, r.unexported)
+}
+
+// copyWith is copy, redacting unexported fields as well if unexported is set.
+func (r *Redactor) copyWith(iface interface{}, unexported bool) (interface{}, error) {
+	if iface == nil {
+		return nil, nil
+	}
+
+	c := r.newCloner(unexported)
+	cp := c.clone(reflect.ValueOf(iface))
+
+	w := r.newWalker()
+	w.unexported = unexported
+
+	if cp.Kind() == reflect.Ptr {
+		if cp.IsNil() {
+			return cp.Interface(), nil
+		}
+		err := r.snapshot(cp, w, Rule{})
+		return cp.Interface(), err
+	}
+
+	ptr := reflect.New(cp.Type())
+	ptr.Elem().Set(cp)
+	err := r.snapshot(ptr, w, Rule{})
+	return ptr.Elem().Interface(), err
+}
+
+// cloneKey identifies a reference in the source graph. Slices are keyed on
+// their length as well, so two different windows over one backing array are
+// cloned separately.
+type cloneKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+type cloner struct {
+	seen map[cloneKey]reflect.Value
+
+	// unexported deep copies unexported fields as well, see WithUnexported
+	unexported bool
+	// gen is passed to generated CloneWith methods, it is nil if they are not
+	// used
+	gen *Clone
+}
+
+// newCloner returns a cloner for copies made by r, which copies unexported
+// fields as well if unexported is set.
+func (r *Redactor) newCloner(unexported bool) *cloner {
+	c := &cloner{seen: map[cloneKey]reflect.Value{}, unexported: unexported}
+	if !unexported && !r.noGenerated {
+		c.gen = &Clone{c: c}
+	}
+	return c
+}
+
+func (c *cloner) clone(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		if c.gen != nil && v.Type().Implements(generatedCopyType) && v.CanInterface() {
+			return reflect.ValueOf(v.Interface().(GeneratedCopy).CloneWith(c.gen))
+		}
+		key := cloneKey{ptr: v.Pointer(), typ: v.Type()}
+		if cp, ok := c.seen[key]; ok {
+			return cp
+		}
+		cp := reflect.New(v.Type().Elem())
+		c.seen[key] = cp
+		cp.Elem().Set(c.clone(v.Elem()))
+		return cp
+	case reflect.Struct:
+		cp := reflect.New(v.Type()).Elem()
+		// copy everything first so unexported fields, which can not be
+		// set through reflection, keep their values
+		cp.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			switch field := cp.Field(i); {
+			case field.CanSet():
+				field.Set(c.clone(v.Field(i)))
+			case c.unexported || isPromoted(v.Type().Field(i)):
+				// cp is a shallow copy of v, so the field still holds the
+				// original references. The exported fields promoted from
+				// embedded structs are redacted, so these are copied as well.
+				field = unexportedField(field)
+				field.Set(c.clone(field))
+			}
+		}
+		return cp
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		key := cloneKey{ptr: v.Pointer(), typ: v.Type(), len: v.Len()}
+		if cp, ok := c.seen[key]; ok {
+			return cp
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		c.seen[key] = cp
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(c.clone(v.Index(i)))
+		}
+		return cp
+	case reflect.Array:
+		cp := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(c.clone(v.Index(i)))
+		}
+		return cp
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		key := cloneKey{ptr: v.Pointer(), typ: v.Type()}
+		if cp, ok := c.seen[key]; ok {
+			return cp
+		}
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		c.seen[key] = cp
+		iter := v.MapRange()
+		for iter.Next() {
+			// keys are cloned too, as a keys= rule redacts what they point to
+			cp.SetMapIndex(c.clone(iter.Key()), c.clone(iter.Value()))
+		}
+		return cp
+	case reflect.Interface:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(c.clone(v.Elem()))
+		return cp
+	default:
+		return v
+	}
+}
+
+// unexportedField returns the addressable unexported struct field as a value
+// that can be read and set.
+func unexportedField(field reflect.Value) reflect.Value {
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+}
@@ -0,0 +1,120 @@
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestSharedPtrs struct {
+	First  *string
+	Second *string
+	List   []*TestStruct
+	Again  []*TestStruct
+}
+
+func TestCopy(t *testing.T) {
+	t.Run("Should redact the copy and leave the original untouched", func(t *testing.T) {
+		sharedVal := nonSnapshotVal
+		tStruct := &TestStruct{
+			NonSnapshot:    nonSnapshotVal,
+			NonSnapshotPtr: &sharedVal,
+			SnapshotStr:    snapshotVal,
+			SnapshotStrPtr: &snapshotPtrVal,
+		}
+
+		cp, err := redact.Copy(tStruct)
+		assert.NoError(t, err, "should not fail to copy struct")
+
+		redacted, ok := cp.(*TestStruct)
+		assert.True(t, ok, "should return the same type that was passed in")
+		assert.NotSame(t, tStruct, redacted, "should return a new pointer")
+
+		assert.Equal(t, snapshotVal, redacted.SnapshotStr, "should contain snapshot value")
+		assert.Equal(t, snapshotPtrVal, *redacted.SnapshotStrPtr, "should contain snapshot value")
+		assert.Equal(t, redact.RedactStrConst, redacted.NonSnapshot, "should redact non snapshot value")
+		assert.Equal(t, redact.RedactStrConst, *redacted.NonSnapshotPtr, "should redact non snapshot pointer value")
+
+		assert.Equal(t, nonSnapshotVal, tStruct.NonSnapshot, "should not modify the original")
+		assert.Equal(t, nonSnapshotVal, *tStruct.NonSnapshotPtr, "should not modify the original")
+		assert.Equal(t, nonSnapshotVal, sharedVal, "should not modify shared pointer targets")
+	})
+
+	t.Run("Should copy values that are not pointers", func(t *testing.T) {
+		tStruct := TestStruct{
+			NonSnapshot: nonSnapshotVal,
+			SnapshotStr: snapshotVal,
+		}
+
+		cp, err := redact.Copy(tStruct)
+		assert.NoError(t, err, "should not fail to copy struct")
+
+		redacted, ok := cp.(TestStruct)
+		assert.True(t, ok, "should return the same type that was passed in")
+		assert.Equal(t, snapshotVal, redacted.SnapshotStr, "should contain snapshot value")
+		assert.Equal(t, redact.RedactStrConst, redacted.NonSnapshot, "should redact non snapshot value")
+		assert.Equal(t, nonSnapshotVal, tStruct.NonSnapshot, "should not modify the original")
+	})
+
+	t.Run("Should copy maps and slices", func(t *testing.T) {
+		tMaps := &TestMaps{
+			NonSnapshotMap: map[string]string{
+				"secret-key": nonSnapshotVal,
+			},
+			TestStructs: map[string]*TestStruct{
+				"ptr-test-struct-key": {
+					NonSnapshot: nonSnapshotVal,
+					SnapshotStr: snapshotVal,
+				},
+			},
+		}
+		list := &TestMapList{Data: []*TestMaps{tMaps}}
+
+		cp, err := redact.Copy(list)
+		assert.NoError(t, err, "should not fail to copy struct")
+
+		redacted := cp.(*TestMapList)
+		assert.Equal(t, redact.RedactStrConst, redacted.Data[0].NonSnapshotMap["secret-key"], "should redact non snapshot value")
+		assert.Equal(t, redact.RedactStrConst, redacted.Data[0].TestStructs["ptr-test-struct-key"].NonSnapshot, "should redact non snapshot value")
+		assert.Equal(t, snapshotVal, redacted.Data[0].TestStructs["ptr-test-struct-key"].SnapshotStr, "should contain snapshot value")
+
+		assert.Equal(t, nonSnapshotVal, tMaps.NonSnapshotMap["secret-key"], "should not modify the original")
+		assert.Equal(t, nonSnapshotVal, tMaps.TestStructs["ptr-test-struct-key"].NonSnapshot, "should not modify the original")
+	})
+
+	t.Run("Should preserve aliasing in the copy", func(t *testing.T) {
+		sharedVal := nonSnapshotVal
+		sharedStruct := &TestStruct{NonSnapshot: nonSnapshotVal}
+		list := []*TestStruct{sharedStruct, sharedStruct}
+		tShared := &TestSharedPtrs{
+			First:  &sharedVal,
+			Second: &sharedVal,
+			List:   list,
+			Again:  list,
+		}
+
+		cp, err := redact.Copy(tShared)
+		assert.NoError(t, err, "should not fail to copy struct")
+
+		redacted := cp.(*TestSharedPtrs)
+		assert.Same(t, redacted.First, redacted.Second, "should keep shared pointers shared")
+		assert.NotSame(t, tShared.First, redacted.First, "should not share pointers with the original")
+		assert.Same(t, redacted.List[0], redacted.List[1], "should keep shared pointers shared")
+		assert.Same(t, &redacted.List[0], &redacted.Again[0], "should keep shared slices shared")
+		assert.Equal(t, redact.RedactStrConst, *redacted.First, "should redact non snapshot value")
+		assert.Equal(t, nonSnapshotVal, sharedVal, "should not modify the original")
+		assert.Equal(t, nonSnapshotVal, sharedStruct.NonSnapshot, "should not modify the original")
+	})
+
+	t.Run("Should return nil for nil input", func(t *testing.T) {
+		cp, err := redact.Copy(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, cp)
+
+		var tStruct *TestStruct
+		cp, err = redact.Copy(tStruct)
+		assert.NoError(t, err)
+		assert.Nil(t, cp.(*TestStruct))
+	})
+}
@@ -0,0 +1,133 @@
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestNode struct {
+	Name     string `redact:"snapshot"`
+	Secret   string
+	Parent   *TestNode
+	Children []*TestNode
+	Next     *TestNode
+	Prev     *TestNode
+	Links    map[string]*TestNode
+}
+
+type TestSharedHash struct {
+	First  *string  `redact:"sha256"`
+	Second *string  `redact:"sha256"`
+	List   []string `redact:"sha256"`
+	Again  []string `redact:"sha256"`
+}
+
+type TestSharedMap struct {
+	Public map[string]string `redact:"values=snapshot"`
+	Secret map[string]string
+}
+
+type TestSharedPointer struct {
+	Public *string `redact:"snapshot"`
+	Secret *string
+}
+
+func TestCycles(t *testing.T) {
+	t.Run("Should terminate on parent and child back pointers", func(t *testing.T) {
+		parent := &TestNode{Name: "parent", Secret: nonSnapshotVal}
+		child := &TestNode{Name: "child", Secret: nonSnapshotVal, Parent: parent}
+		parent.Children = []*TestNode{child, child}
+
+		err := redact.Snapshot(parent)
+		assert.NoError(t, err, "should not fail to redact cyclic struct")
+
+		assert.Equal(t, "parent", parent.Name, "should contain snapshot value")
+		assert.Equal(t, redact.RedactStrConst, parent.Secret, "should redact non snapshot value")
+		assert.Equal(t, redact.RedactStrConst, child.Secret, "should redact non snapshot value")
+	})
+
+	t.Run("Should terminate on circular linked lists", func(t *testing.T) {
+		first := &TestNode{Secret: nonSnapshotVal}
+		second := &TestNode{Secret: nonSnapshotVal, Prev: first, Next: first}
+		first.Next, first.Prev = second, second
+
+		err := redact.Snapshot(first)
+		assert.NoError(t, err, "should not fail to redact cyclic list")
+		assert.Equal(t, redact.RedactStrConst, first.Secret, "should redact non snapshot value")
+		assert.Equal(t, redact.RedactStrConst, second.Secret, "should redact non snapshot value")
+	})
+
+	t.Run("Should terminate on cyclic maps and slices", func(t *testing.T) {
+		node := &TestNode{Secret: nonSnapshotVal}
+		node.Links = map[string]*TestNode{"self": node}
+
+		list := []interface{}{nonSnapshotVal, nil}
+		list[1] = list
+
+		m := map[string]interface{}{"secret": nonSnapshotVal}
+		m["self"] = m
+
+		assert.NoError(t, redact.Snapshot(node), "should not fail to redact cyclic map")
+		assert.NoError(t, redact.Snapshot(&list), "should not fail to redact cyclic slice")
+		assert.NoError(t, redact.Snapshot(&m), "should not fail to redact cyclic map")
+
+		assert.Equal(t, redact.RedactStrConst, node.Secret, "should redact non snapshot value")
+		assert.Equal(t, redact.RedactStrConst, list[0], "should redact non snapshot value")
+		assert.Equal(t, redact.RedactStrConst, m["secret"], "should redact non snapshot value")
+	})
+
+	t.Run("Should copy cycles as cycles", func(t *testing.T) {
+		parent := &TestNode{Name: "parent", Secret: nonSnapshotVal}
+		child := &TestNode{Name: "child", Secret: nonSnapshotVal, Parent: parent}
+		parent.Children = []*TestNode{child}
+		parent.Links = map[string]*TestNode{"self": parent}
+
+		redacted, err := redact.Redacted(parent)
+		assert.NoError(t, err, "should not fail to copy cyclic struct")
+
+		assert.NotSame(t, parent, redacted, "should copy the struct")
+		assert.Same(t, redacted, redacted.Children[0].Parent, "should keep back pointers in the copy")
+		assert.Same(t, redacted, redacted.Links["self"], "should keep cycles through maps in the copy")
+		assert.Equal(t, redact.RedactStrConst, redacted.Children[0].Secret, "should redact the copy")
+		assert.Equal(t, nonSnapshotVal, child.Secret, "should not modify the original")
+	})
+
+	t.Run("Should redact shared values once", func(t *testing.T) {
+		shared := "hunter2"
+		list := []string{"hunter2"}
+		tStruct := &TestSharedHash{First: &shared, Second: &shared, List: list, Again: list}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		hash := "f52fbd32b2b3b86ff88ef6c490628285f482af15ddcb29541f94bcf526a3f6c7"
+		assert.Equal(t, hash, *tStruct.First, "should hash the shared value once")
+		assert.Equal(t, hash, *tStruct.Second, "should hash the shared value once")
+		assert.Equal(t, hash, tStruct.List[0], "should hash the shared slice once")
+		assert.Equal(t, hash, tStruct.Again[0], "should hash the shared slice once")
+	})
+
+	t.Run("Should redact shared values with every rule that reaches them", func(t *testing.T) {
+		shared := map[string]string{"k": nonSnapshotVal}
+		tMap := &TestSharedMap{Public: shared, Secret: shared}
+
+		err := redact.Snapshot(tMap)
+		assert.NoError(t, err, "should not fail to redact struct")
+		assert.Equal(t, redact.RedactStrConst, tMap.Secret["k"], "should redact the map shared with a kept field")
+
+		secret := nonSnapshotVal
+		tPointer := &TestSharedPointer{Public: &secret, Secret: &secret}
+
+		err = redact.New(redact.WithStrict()).Snapshot(tPointer)
+		assert.NoError(t, err, "should not fail to redact struct")
+		assert.Equal(t, redact.RedactStrConst, *tPointer.Secret, "should redact the pointer shared with a kept field")
+
+		secret = nonSnapshotVal
+		copied, err := redact.Redacted(&TestSharedPointer{Public: &secret, Secret: &secret})
+		assert.NoError(t, err, "should not fail to copy struct")
+		assert.Equal(t, redact.RedactStrConst, *copied.Secret, "should redact the copy")
+		assert.Equal(t, nonSnapshotVal, secret, "should not modify the original")
+	})
+}
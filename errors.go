@@ -0,0 +1,103 @@
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNotPointer is returned by Snapshot for a value that is not a pointer.
+	ErrNotPointer = errors.New("Not a pointer")
+
+	// ErrUnknownRule is reported in strict mode for a tag naming a rule that
+	// is not registered.
+	ErrUnknownRule = errors.New("unknown redact rule")
+	// ErrUnsupportedKind is reported in strict mode for a value that can not be
+	// redacted, such as a channel or a func, or a rule that does not apply to
+	// the kind of value it is used on.
+	ErrUnsupportedKind = errors.New("unsupported kind")
+	// ErrUnexportedField is reported in strict mode for an unexported field the
+	// walker can not write to.
+	ErrUnexportedField = errors.New("unexported field")
+	// ErrKeyCollision is reported for each map entry dropped because its key,
+	// which is not a string, is redacted by a keys= rule to the key of
+	// another entry.
+	ErrKeyCollision = errors.New("redacted map key collides with another key")
+	// ErrRedactableResult is reported when the Redact method of a Redactable
+	// returns a value of another type. The value is set to its zero value.
+	ErrRedactableResult = errors.New("Redact returned a value of the wrong type")
+)
+
+// FieldError is an error redacting the value at Path, such as a malformed tag
+// or a redactor that failed. The value is still redacted, with the placeholder.
+//
+// Path looks like `Data[3].Address.Street`. Map entries show as [*] since their
+// keys may be sensitive themselves.
+type FieldError struct {
+	Path string
+	Type reflect.Type
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("redact %s: %v", e.Type, e.Err)
+	}
+	return fmt.Sprintf("redact %s (%s): %v", e.Path, e.Type, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Errors holds all errors of a single Snapshot or Copy call, in the order the
+// values were walked. errors.Is and errors.As look at every error.
+type Errors []error
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strconv.Itoa(len(e)) + " errors: " + strings.Join(msgs, "; ")
+}
+
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// mapEntry is the index of a pathElem for a map entry.
+const mapEntry = -1
+
+// pathElem is a struct field, a slice or array index, or a map entry.
+type pathElem struct {
+	field string
+	index int
+}
+
+func formatPath(path []pathElem) string {
+	var b strings.Builder
+	for _, elem := range path {
+		switch {
+		case elem.field != "":
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(elem.field)
+		case elem.index == mapEntry:
+			b.WriteString("[*]")
+		default:
+			b.WriteByte('[')
+			b.WriteString(strconv.Itoa(elem.index))
+			b.WriteByte(']')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package redact_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestAddress struct {
+	Street string `redact:"truncate(len=x)"`
+	City   string `redact:"snapshot"`
+}
+
+type TestPerson struct {
+	Address *TestAddress
+	Tags    map[string]TestAddress
+	Phone   string `redact:"mask(first=1"`
+}
+
+type TestPeople struct {
+	Data []TestPerson
+}
+
+func TestErrors(t *testing.T) {
+	t.Run("Should return ErrNotPointer", func(t *testing.T) {
+		err := redact.Snapshot(TestStruct{})
+		assert.ErrorIs(t, err, redact.ErrNotPointer)
+	})
+
+	t.Run("Should collect errors with field paths", func(t *testing.T) {
+		people := &TestPeople{
+			Data: []TestPerson{
+				{},
+				{
+					Address: &TestAddress{Street: "Main Street", City: "Seattle"},
+					Tags:    map[string]TestAddress{"home": {Street: "Elm Street"}},
+				},
+			},
+		}
+
+		err := redact.Snapshot(people)
+		assert.Error(t, err, "should report errors")
+
+		var errs redact.Errors
+		assert.True(t, errors.As(err, &errs), "should return all errors")
+
+		var paths []string
+		for _, err := range errs {
+			var fieldErr *redact.FieldError
+			assert.True(t, errors.As(err, &fieldErr), "should return field errors")
+			paths = append(paths, fieldErr.Path)
+		}
+		assert.Equal(t, []string{
+			"Data[0].Phone",
+			"Data[1].Address.Street",
+			"Data[1].Tags[*].Street",
+			"Data[1].Phone",
+		}, paths, "should report every failing field in walk order")
+
+		var fieldErr *redact.FieldError
+		assert.True(t, errors.As(err, &fieldErr), "should find field errors through the collection")
+		assert.Equal(t, "Data[0].Phone", fieldErr.Path)
+		assert.Equal(t, reflect.TypeOf(""), fieldErr.Type)
+		assert.ErrorIs(t, err, redact.ErrInvalidTag, "should find wrapped errors through the collection")
+
+		assert.Equal(t, "Seattle", people.Data[1].Address.City, "should contain snapshot value")
+		assert.Equal(t, redact.RedactStrConst, people.Data[1].Address.Street, "should still redact failing fields")
+	})
+
+	t.Run("Should format errors", func(t *testing.T) {
+		err := redact.Snapshot(&TestAddress{Street: "Main Street"})
+		assert.EqualError(t, err, `redact Street (string): rule truncate: argument len: "x" is not an integer`)
+
+		err = redact.Snapshot(&TestPerson{Address: &TestAddress{}})
+		assert.Contains(t, err.Error(), "2 errors: ")
+	})
+
+	t.Run("Should return nil without errors", func(t *testing.T) {
+		err := redact.Snapshot(&TestStruct{})
+		assert.Nil(t, err, "should return an untyped nil")
+	})
+}
@@ -0,0 +1,91 @@
+package redact_test
+
+import (
+	"fmt"
+
+	"github.com/samkreter/redact"
+)
+
+func ExampleSnapshot() {
+	type User struct {
+		Name     string `redact:"snapshot"`
+		Password string
+	}
+
+	user := &User{Name: "gopher", Password: "hunter2"}
+	redact.Snapshot(user)
+
+	fmt.Println(user.Name, user.Password)
+	// Output: gopher NONSNAPSHOT
+}
+
+func ExampleSnapshot_builtinRedactors() {
+	type Account struct {
+		Card     string `redact:"mask"`
+		Phone    string `redact:"mask(first=2,last=2)"`
+		IBAN     string `redact:"mask(keep=4)"`
+		SSN      string `redact:"last4"`
+		Token    string `redact:"fixed(8)"`
+		Secret   string `redact:"sha256"`
+		Note     string `redact:"truncate(5)"`
+		Email    string `redact:"email"`
+		Internal string `redact:"empty"`
+		Password string `redact:"len"`
+	}
+
+	account := &Account{
+		Card:     "4111111111111111",
+		Phone:    "5551234567",
+		IBAN:     "DE89370400440532013000",
+		SSN:      "078-05-1120",
+		Token:    "abc",
+		Secret:   "hunter2",
+		Note:     "customer called about refund",
+		Email:    "gopher@example.com",
+		Internal: "internal only",
+		Password: "hunter2",
+	}
+	redact.Snapshot(account)
+
+	fmt.Println(account.Card)
+	fmt.Println(account.Phone)
+	fmt.Println(account.IBAN)
+	fmt.Println(account.SSN)
+	fmt.Println(account.Token)
+	fmt.Println(account.Secret)
+	fmt.Println(account.Note)
+	fmt.Println(account.Email)
+	fmt.Printf("%q\n", account.Internal)
+	fmt.Println(account.Password)
+	// Output:
+	// ****************
+	// 55******67
+	// ******************3000
+	// *******1120
+	// ********
+	// f52fbd32b2b3b86ff88ef6c490628285f482af15ddcb29541f94bcf526a3f6c7
+	// custo
+	// ******@example.com
+	// ""
+	// 7
+}
+
+func ExampleRegisterRedactor() {
+	type Order struct {
+		Customer string `redact:"initials"`
+	}
+
+	redact.RegisterRedactor("initials", func(input string) string {
+		if input == "" {
+			return input
+		}
+		return input[:1] + "."
+	})
+	defer redact.UnregisterRedactor("initials")
+
+	order := &Order{Customer: "Gopher"}
+	redact.Snapshot(order)
+
+	fmt.Println(order.Customer)
+	// Output: G.
+}
@@ -0,0 +1,54 @@
+package redact
+
+import (
+	"fmt"
+)
+
+// Format returns a view of v for package fmt that prints v redacted with the
+// default Redactor, see Redactor.Format.
+func Format(v interface{}) Formatted {
+	return defaultRedactor.Format(v)
+}
+
+// Format returns a view of v for package fmt that prints v redacted, e.g.
+//
+//	log.Printf("%+v", redact.Format(req))
+//
+// v is redacted on demand each time the view is printed, into a copy made like
+// Copy, so v itself is left untouched. If redacting fails the values that
+// failed print as the placeholder.
+//
+// Package fmt prints unexported fields too, so they are redacted by their tags
+// like the exported ones, as with WithUnexported. This includes the fields of
+// values such as time.Time, which need the snapshot rule to print as they are.
+func (r *Redactor) Format(v interface{}) Formatted {
+	return Formatted{r: r, v: v}
+}
+
+// Formatted is a redacted view of a value for package fmt, see Format. It
+// supports the verbs and flags the value itself does, e.g. %v, %+v, %#v and %s.
+type Formatted struct {
+	r *Redactor
+	v interface{}
+}
+
+// Format implements fmt.Formatter by printing a redacted copy of the value
+// with the same verb and flags.
+func (f Formatted) Format(s fmt.State, verb rune) {
+	fmt.Fprintf(s, fmt.FormatString(s, verb), f.redacted())
+}
+
+// String implements fmt.Stringer, like %v.
+func (f Formatted) String() string {
+	return fmt.Sprint(f.redacted())
+}
+
+// GoString implements fmt.GoStringer, like %#v.
+func (f Formatted) GoString() string {
+	return fmt.Sprintf("%#v", f.redacted())
+}
+
+func (f Formatted) redacted() interface{} {
+	cp, _ := f.r.copyWith(f.v, true)
+	return cp
+}
@@ -0,0 +1,75 @@
+package redact_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestFormatStruct struct {
+	ID       string `redact:"snapshot"`
+	Card     string `redact:"last4"`
+	Password string
+}
+
+type testFormatInner struct {
+	Secret string
+}
+
+type TestFormatOuter struct {
+	testFormatInner
+	ID      string `redact:"snapshot"`
+	token   string
+	Created time.Time `redact:"snapshot"`
+}
+
+func TestFormat(t *testing.T) {
+	newStruct := func() *TestFormatStruct {
+		return &TestFormatStruct{ID: "id-1", Card: "4111111111111111", Password: "hunter2"}
+	}
+
+	t.Run("Should print the redacted view with every verb", func(t *testing.T) {
+		tStruct := newStruct()
+		formatted := redact.Format(tStruct)
+
+		assert.Equal(t, "&{id-1 ************1111 NONSNAPSHOT}", fmt.Sprintf("%v", formatted), "should print %v")
+		assert.Equal(t, "&{ID:id-1 Card:************1111 Password:NONSNAPSHOT}", fmt.Sprintf("%+v", formatted), "should print %+v")
+		assert.Equal(t, `&redact_test.TestFormatStruct{ID:"id-1", Card:"************1111", Password:"NONSNAPSHOT"}`, fmt.Sprintf("%#v", formatted), "should print %#v")
+		assert.Equal(t, "&{id-1 ************1111 NONSNAPSHOT}", fmt.Sprintf("%s", formatted), "should print %s")
+		assert.Equal(t, "&{id-1 ************1111 NONSNAPSHOT}", formatted.String(), "should implement fmt.Stringer")
+		assert.Equal(t, fmt.Sprintf("%#v", formatted), formatted.GoString(), "should implement fmt.GoStringer")
+
+		assert.Equal(t, newStruct(), tStruct, "should not modify the original")
+	})
+
+	t.Run("Should print the value as it is when printed", func(t *testing.T) {
+		tStruct := newStruct()
+		formatted := redact.Format(*tStruct)
+		tStruct.ID = "id-2"
+
+		assert.Equal(t, "{id-1 ************1111 NONSNAPSHOT}", fmt.Sprint(formatted), "should print values that are not pointers")
+
+		formatted = redact.Format(tStruct)
+		tStruct.ID = "id-3"
+		assert.Equal(t, "&{id-3 ************1111 NONSNAPSHOT}", fmt.Sprint(formatted), "should redact on demand")
+	})
+
+	t.Run("Should honour flags and redactor options", func(t *testing.T) {
+		formatted := redact.New(redact.WithPlaceholder("***")).Format([]string{"a", "b"})
+
+		assert.Equal(t, `["***" "***"]`, fmt.Sprintf("%q", formatted), "should pass the verb on")
+		assert.Equal(t, "[***        ***       ]", fmt.Sprintf("%-10s", formatted), "should pass flags on")
+		assert.Equal(t, "<nil>", fmt.Sprint(redact.Format(nil)), "should print nil")
+	})
+
+	t.Run("Should redact unexported fields", func(t *testing.T) {
+		created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		formatted := redact.Format(TestFormatOuter{testFormatInner{"secret"}, "id", "token", created})
+
+		assert.Equal(t, "{testFormatInner:{Secret:NONSNAPSHOT} ID:id token:NONSNAPSHOT Created:"+created.String()+"}",
+			fmt.Sprintf("%+v", formatted), "should not leak unexported fields")
+	})
+}
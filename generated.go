@@ -0,0 +1,228 @@
+package redact
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Generated is implemented by types with methods generated by cmd/redactgen.
+// The walker calls RedactWith instead of walking such values with reflection,
+// unless the Redactor reads another tag key, runs in strict mode, redacts
+// unexported fields or was created with WithoutGenerated.
+type Generated interface {
+	RedactWith(g *Gen, rule Rule)
+}
+
+// GeneratedCopy is implemented by types with a deep copy generated by
+// cmd/redactgen. Copy calls CloneWith instead of copying such values with
+// reflection, unless the Redactor copies unexported fields or was created
+// with WithoutGenerated.
+type GeneratedCopy interface {
+	CloneWith(c *Clone) interface{}
+}
+
+// Gen is the state of a walk passed to generated RedactWith methods. Its
+// methods and the functions taking a *Gen are meant for generated code only.
+type Gen struct {
+	w *walker
+}
+
+// Clone is the state of a deep copy passed to generated CloneWith and
+// CloneInto methods. Its methods and the functions taking a *Clone are meant
+// for generated code only.
+type Clone struct {
+	c *cloner
+}
+
+var (
+	generatedType     = reflect.TypeOf((*Generated)(nil)).Elem()
+	generatedCopyType = reflect.TypeOf((*GeneratedCopy)(nil)).Elem()
+)
+
+// redactGenerated lets the generated RedactWith method redact the value ifv
+// points to. It reports whether there was one.
+func (w *walker) redactGenerated(ifv reflect.Value, plan *typePlan, rule Rule) bool {
+	if !plan.generated || w.strict || w.unexported || w.uncompiled || w.noGenerated || w.tagName != tagName {
+		return false
+	}
+	if !ifv.CanInterface() {
+		return false
+	}
+
+	ifv.Interface().(Generated).RedactWith(&Gen{w: w}, rule)
+	return true
+}
+
+// SnapshotGenerated redacts the value v points to in place like Snapshot,
+// with the generated RedactWith method of its type. It is what generated
+// RedactSnapshot methods call.
+func SnapshotGenerated[T any, PT interface {
+	*T
+	Generated
+}](v PT) error {
+	w := defaultRedactor.newWalker()
+	w.checkDefaultRule()
+	g := &Gen{w: w}
+	if Enter(g, (*T)(v), Rule{}) {
+		v.RedactWith(g, Rule{})
+	}
+	if len(w.errs) > 0 {
+		return w.errs
+	}
+	return nil
+}
+
+// CopyGenerated returns a redacted deep copy of the value v points to like
+// Redacted, made and redacted with the generated methods of its type. It is
+// what generated RedactedCopy methods call.
+func CopyGenerated[T any, PT interface {
+	*T
+	Generated
+	CloneInto(c *Clone, cp *T)
+}](v PT) (PT, error) {
+	if v == nil {
+		return nil, nil
+	}
+	c := defaultRedactor.newCloner(defaultRedactor.unexported)
+	cp := PT(ClonePtr[T, PT](c.gen, v))
+
+	w := defaultRedactor.newWalker()
+	w.checkDefaultRule()
+	g := &Gen{w: w}
+	if Enter(g, (*T)(cp), Rule{}) {
+		cp.RedactWith(g, Rule{})
+	}
+	if len(w.errs) > 0 {
+		return nil, w.errs
+	}
+	return cp, nil
+}
+
+// Field enters the struct field name, Pop leaves it again.
+func (g *Gen) Field(name string) {
+	g.w.push(pathElem{field: name})
+}
+
+// Pop leaves the field entered last.
+func (g *Gen) Pop() {
+	g.w.pop()
+}
+
+// Inherit returns the rule for a field tagged with field inside a struct
+// redacted with parent.
+func (g *Gen) Inherit(field, parent Rule) Rule {
+	parent.Keys, parent.Values = nil, nil
+	return inheritRule(field, parent)
+}
+
+// Enter reports whether the value p points to needs to be redacted with rule,
+// i.e. p is not nil and it was not redacted with rule before. Pointers can be
+// shared and form cycles, fields held by value are redacted without it.
+func Enter[T any](g *Gen, p *T, rule Rule) bool {
+	return p != nil && g.w.visit(reflect.ValueOf(p), rule)
+}
+
+// Value redacts the value p points to with reflection, for fields the
+// generator has no special case for.
+func (g *Gen) Value(p interface{}, rule Rule) {
+	g.w.snapshotHelper(reflect.ValueOf(p), rule)
+}
+
+// String redacts the string p points to with rule.
+func String[T ~string](g *Gen, p *T, rule Rule) {
+	if isKept(g.w.resolveRule(rule)) {
+		return
+	}
+
+	output, err := g.w.transformString(string(*p), rule)
+	if err != nil {
+		g.w.fail(reflect.TypeOf(p).Elem(), err)
+	}
+	*p = T(output)
+}
+
+// Bool redacts the bool p points to with rule, see Number.
+func Bool[T ~bool](g *Gen, p *T, rule Rule) {
+	rule = g.w.resolveRule(rule)
+	if isKept(rule) || !bool(*p) && rule.HasFlag("omitempty") {
+		return
+	}
+	*p = false
+}
+
+// Number redacts the number p points to with rule. Rules that change numbers,
+// such as round, are applied by the code the reflection walker uses.
+func Number[T ~int | ~int8 | ~int16 | ~int32 | ~int64 |
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+	~float32 | ~float64](g *Gen, p *T, rule Rule) {
+	resolved := g.w.resolveRule(rule)
+	if isKept(resolved) || *p == 0 && resolved.HasFlag("omitempty") {
+		return
+	}
+
+	switch _, ok := numberRedactors[resolved.Name]; {
+	case resolved.Name == "omit":
+		*p = 0
+	case ok:
+		ref := reflect.ValueOf(p).Elem()
+		if err := g.w.transformScalar(ref, rule); err != nil {
+			g.w.fail(ref.Type(), err)
+		}
+	default:
+		// the numeric placeholder, or zero if T can not hold it
+		n := g.w.numericPlaceholder
+		placeholder := T(n)
+		if int64(placeholder) != n || n < 0 && T(0)-1 > 0 {
+			placeholder = 0
+		}
+		*p = placeholder
+	}
+}
+
+// ClonePtr returns the deep copy of the value p points to, made with its
+// generated CloneInto method. A pointer that was copied before returns the
+// same copy, so shared values and cycles are copied as they are.
+func ClonePtr[T any, PT interface {
+	*T
+	CloneInto(c *Clone, cp *T)
+}](c *Clone, p PT) *T {
+	if p == nil {
+		return nil
+	}
+	key := cloneKey{ptr: uintptr(unsafe.Pointer(p)), typ: reflect.TypeOf(p)}
+	if cp, ok := c.c.seen[key]; ok {
+		return cp.Interface().(*T)
+	}
+
+	cp := new(T)
+	c.c.seen[key] = reflect.ValueOf(cp)
+	p.CloneInto(c, cp)
+	return cp
+}
+
+// ClonePtrTo returns a copy of the string, bool or number p points to, the
+// same copy for pointers that were copied before.
+func ClonePtrTo[T ~string | ~bool | ~int | ~int8 | ~int16 | ~int32 | ~int64 |
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+	~float32 | ~float64](c *Clone, p *T) *T {
+	if p == nil {
+		return nil
+	}
+	key := cloneKey{ptr: uintptr(unsafe.Pointer(p)), typ: reflect.TypeOf(p)}
+	if cp, ok := c.c.seen[key]; ok {
+		return cp.Interface().(*T)
+	}
+
+	cp := new(T)
+	*cp = *p
+	c.c.seen[key] = reflect.ValueOf(cp)
+	return cp
+}
+
+// Value replaces the value p points to, which holds the original, with a
+// deep copy made with reflection, for fields the generator has no special
+// case for.
+func (c *Clone) Value(p interface{}) {
+	v := reflect.ValueOf(p).Elem()
+	v.Set(c.c.clone(v))
+}
@@ -0,0 +1,635 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httputil"
+	"net/textproto"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxBody is the number of body bytes dumped when HTTPPolicy.MaxBody
+// is not set.
+const DefaultMaxBody = 64 << 10
+
+// SensitiveHeaders are redacted by DumpRequest and DumpResponse unless they
+// are listed in HTTPPolicy.AllowHeaders.
+var SensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+// HTTPPolicy says what DumpRequest and DumpResponse redact. Rules are redact
+// tags, e.g. "snapshot" or "last4"; "redact" stands for the default rule.
+type HTTPPolicy struct {
+	// AllowHeaders lists headers that are dumped as they are. If it is set
+	// every other header is redacted, otherwise only SensitiveHeaders and
+	// DenyHeaders are.
+	AllowHeaders []string
+	// DenyHeaders lists headers that are redacted, also if they are allowed.
+	DenyHeaders []string
+
+	// The values of the cookies in Cookie and Set-Cookie headers are redacted
+	// with CookieRule, the default rule if it is empty, unless they are listed
+	// in AllowCookies. Cookie names and attributes are kept. The allow list of
+	// headers does not apply to the two headers, the deny list does.
+	AllowCookies []string
+	CookieRule   string
+
+	// Query maps query parameter names to rules. Form does the same for the
+	// fields of form and multipart bodies, including files. Parameters and
+	// fields that are not listed are redacted with the rule for "*", or the
+	// default rule if there is none. Query also applies to the URLs in Referer
+	// and Location headers.
+	Query map[string]string
+	Form  map[string]string
+
+	// JSON redacts JSON bodies, e.g. the Redact method of a jsonpath.Redactor.
+	// By default every string, number and bool in them is redacted with the
+	// default rule.
+	JSON func(body []byte) ([]byte, error)
+
+	// MaxBody is the number of body bytes that are read for the dump,
+	// DefaultMaxBody if it is 0. Longer bodies, bodies of other content types
+	// and compressed bodies are dumped as the placeholder. A negative MaxBody
+	// leaves bodies out.
+	MaxBody int64
+}
+
+// DumpRequest is like httputil.DumpRequest but redacts the dump with the
+// default Redactor, see Redactor.DumpRequest.
+func DumpRequest(req *http.Request, policy HTTPPolicy) ([]byte, error) {
+	return defaultRedactor.DumpRequest(req, policy)
+}
+
+// DumpRequest is like httputil.DumpRequest but redacts headers, cookies, the
+// query and the body as policy says. It works for
+// incoming as well as outgoing requests.
+//
+// The body is read up to policy.MaxBody bytes and put back in front of the
+// rest, so the request can still be sent or handled.
+//
+// The dump is returned also if there are errors: values that failed to redact
+// hold the placeholder, so the dump is safe to show.
+func (r *Redactor) DumpRequest(req *http.Request, policy HTTPPolicy) ([]byte, error) {
+	body, complete, err := peekBody(&req.Body, policy.maxBody())
+	if err != nil {
+		return nil, err
+	}
+	return r.dumpRequest(req, policy, body, complete)
+}
+
+// DumpResponse is like httputil.DumpResponse but redacts the dump with the
+// default Redactor, see Redactor.DumpResponse.
+func DumpResponse(resp *http.Response, policy HTTPPolicy) ([]byte, error) {
+	return defaultRedactor.DumpResponse(resp, policy)
+}
+
+// DumpResponse is like httputil.DumpResponse but redacts headers, cookies and
+// the body as policy says. The body is put back as it is for DumpRequest.
+func (r *Redactor) DumpResponse(resp *http.Response, policy HTTPPolicy) ([]byte, error) {
+	body, complete, err := peekBody(&resp.Body, policy.maxBody())
+	if err != nil {
+		return nil, err
+	}
+	return r.dumpResponse(resp, policy, body, complete)
+}
+
+// DumpFunc receives the redacted dumps of NewTransport and Middleware, with
+// the errors redacting them. A nil DumpFunc discards them.
+type DumpFunc func(dump []byte, err error)
+
+// orDiscard returns dump, or a DumpFunc doing nothing if dump is nil.
+func (dump DumpFunc) orDiscard() DumpFunc {
+	if dump == nil {
+		return func([]byte, error) {}
+	}
+	return dump
+}
+
+// NewTransport returns an http.RoundTripper that passes each dump of a
+// request and its response, redacted with the default Redactor, to dump, see
+// Redactor.NewTransport.
+func NewTransport(next http.RoundTripper, policy HTTPPolicy, dump DumpFunc) http.RoundTripper {
+	return defaultRedactor.NewTransport(next, policy, dump)
+}
+
+// NewTransport returns an http.RoundTripper that sends requests with next,
+// http.DefaultTransport if it is nil, and passes the redacted dumps of each
+// request and response to dump, see DumpRequest.
+//
+// The request is dumped before it is sent. The response is dumped once its
+// body was read to the end or closed, so streamed responses are passed on
+// as they arrive; the body is not dumped if it was closed before its end.
+func (r *Redactor) NewTransport(next http.RoundTripper, policy HTTPPolicy, dump DumpFunc) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{r: r, next: next, policy: policy, dump: dump.orDiscard()}
+}
+
+type transport struct {
+	r      *Redactor
+	next   http.RoundTripper
+	policy HTTPPolicy
+	dump   DumpFunc
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request, so the body is put back
+	// into a shallow copy
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+	}
+	t.dump(t.r.DumpRequest(req, t.policy))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body == nil || resp.Body == http.NoBody {
+		t.dump(t.r.dumpResponse(resp, t.policy, nil, true))
+		return resp, nil
+	}
+
+	// the head is kept as it was received, the caller may change resp
+	head := *resp
+	head.Header = resp.Header.Clone()
+	resp.Body = &teeBody{ReadCloser: resp.Body, max: t.policy.maxBody(), done: func(body []byte, complete bool) {
+		t.dump(t.r.dumpResponse(&head, t.policy, body, complete))
+	}}
+	return resp, nil
+}
+
+// teeBody keeps the start of a body as it is read and calls done with it
+// once, when the body reaches its end or is closed. complete is set if the
+// whole body was kept.
+type teeBody struct {
+	io.ReadCloser
+	max  int64
+	done func(body []byte, complete bool)
+
+	// mu guards buf and read, Close may be called while a Read is running
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	read int64
+	once sync.Once
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.max - int64(b.buf.Len()); room > 0 {
+		if int64(n) < room {
+			room = int64(n)
+		}
+		b.buf.Write(p[:room])
+	}
+	b.read += int64(n)
+	if err == io.EOF {
+		b.finish(b.read <= b.max)
+	}
+	return n, err
+}
+
+func (b *teeBody) Close() error {
+	err := b.ReadCloser.Close()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.finish(false)
+	return err
+}
+
+// finish calls done unless it was called before. b.mu must be held.
+func (b *teeBody) finish(complete bool) {
+	b.once.Do(func() {
+		b.done(b.buf.Bytes(), complete)
+	})
+}
+
+// Middleware returns HTTP server middleware that passes the redacted dumps of
+// each request and its response, redacted with the default Redactor, to dump,
+// see Redactor.Middleware.
+func Middleware(policy HTTPPolicy, dump DumpFunc) func(http.Handler) http.Handler {
+	return defaultRedactor.Middleware(policy, dump)
+}
+
+// Middleware returns HTTP server middleware that passes the redacted dumps of
+// each request and the response written for it to dump, see DumpRequest. The
+// request is dumped before the handler runs, the response after it returned.
+func (r *Redactor) Middleware(policy HTTPPolicy, dump DumpFunc) func(http.Handler) http.Handler {
+	dump = dump.orDiscard()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			dump(r.DumpRequest(req, policy))
+
+			rec := &responseRecorder{ResponseWriter: w, max: policy.maxBody(), status: http.StatusOK}
+			next.ServeHTTP(rec, req)
+
+			resp := &http.Response{
+				Status:     fmt.Sprintf("%d %s", rec.status, http.StatusText(rec.status)),
+				StatusCode: rec.status,
+				Proto:      req.Proto,
+				ProtoMajor: req.ProtoMajor,
+				ProtoMinor: req.ProtoMinor,
+				Header:     w.Header().Clone(),
+				// the length written, rather than a Content-Length of 0
+				ContentLength: rec.written,
+				Request:       req,
+			}
+			dump(r.dumpResponse(resp, policy, rec.body.Bytes(), rec.written <= rec.max))
+		})
+	}
+}
+
+// responseRecorder keeps the status and the start of the body written to a
+// ResponseWriter.
+type responseRecorder struct {
+	http.ResponseWriter
+	max     int64
+	status  int
+	body    bytes.Buffer
+	written int64
+	// wroteHeader is set once the status is sent, later ones are ignored
+	wroteHeader bool
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	if room := w.max - int64(w.body.Len()); room > 0 {
+		if int64(len(p)) < room {
+			room = int64(len(p))
+		}
+		w.body.Write(p[:room])
+	}
+	w.written += int64(len(p))
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher if the wrapped ResponseWriter does.
+func (w *responseRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped ResponseWriter, for http.ResponseController.
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (p HTTPPolicy) maxBody() int64 {
+	if p.MaxBody == 0 {
+		return DefaultMaxBody
+	}
+	return p.MaxBody
+}
+
+// peekBody reads up to max bytes of *body and puts them back in front of the
+// rest. complete is set if that was all of it.
+func peekBody(body *io.ReadCloser, max int64) (data []byte, complete bool, err error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, true, nil
+	}
+	if max < 0 {
+		return nil, false, nil
+	}
+
+	orig := *body
+	data, err = io.ReadAll(io.LimitReader(orig, max+1))
+	*body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(data), orig), Closer: orig}
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(data)) > max {
+		return data[:max], false, nil
+	}
+	return data, true, nil
+}
+
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// httpDump is the state of redacting one dump.
+type httpDump struct {
+	r      *Redactor
+	policy HTTPPolicy
+	errs   Errors
+}
+
+func (r *Redactor) dumpRequest(req *http.Request, policy HTTPPolicy, body []byte, complete bool) ([]byte, error) {
+	d := &httpDump{r: r, policy: policy}
+
+	head := req.Clone(req.Context())
+	head.Body = nil
+	head.Header = d.headers(req.Header)
+	if head.ProtoMajor == 0 {
+		// outgoing requests need not set it, redirects made by http.Client
+		// do not, and the client sends HTTP/1.1 or newer
+		head.Proto, head.ProtoMajor, head.ProtoMinor = "HTTP/1.1", 1, 1
+	}
+	if req.URL != nil {
+		head.URL.RawQuery = d.query(req.URL.Query())
+		if req.RequestURI != "" {
+			head.RequestURI = head.URL.RequestURI()
+		}
+	}
+
+	dump, err := httputil.DumpRequest(head, false)
+	if err != nil {
+		return nil, err
+	}
+	dump = append(dump, d.body(req.Header, body, complete)...)
+	return dump, d.err()
+}
+
+func (r *Redactor) dumpResponse(resp *http.Response, policy HTTPPolicy, body []byte, complete bool) ([]byte, error) {
+	d := &httpDump{r: r, policy: policy}
+
+	head := *resp
+	head.Header = d.headers(resp.Header)
+	head.Body = nil
+
+	dump, err := httputil.DumpResponse(&head, false)
+	if err != nil {
+		return nil, err
+	}
+	dump = append(dump, d.body(resp.Header, body, complete)...)
+	return dump, d.err()
+}
+
+func (d *httpDump) err() error {
+	if len(d.errs) > 0 {
+		return d.errs
+	}
+	return nil
+}
+
+var stringType = reflect.TypeOf("")
+
+// transform applies rule to the value at path, recording errors.
+func (d *httpDump) transform(path, value string, rule Rule) string {
+	output, err := d.r.transformString(value, rule)
+	if err != nil {
+		d.errs = append(d.errs, &FieldError{Path: path, Type: stringType, Err: err})
+	}
+	return output
+}
+
+// rule parses the tag of a policy entry at path, "redact" standing for the
+// default rule. A tag that does not parse redacts with the default rule.
+func (d *httpDump) rule(path, tag string) Rule {
+	rule, err := ParseRule(tag)
+	if err != nil {
+		d.errs = append(d.errs, &FieldError{Path: path, Type: stringType, Err: err})
+		return Rule{}
+	}
+	if rule.Name == "redact" {
+		rule.Name = ""
+	}
+	return rule
+}
+
+// named returns the rule for name in rules, falling back to the rule for "*".
+func (d *httpDump) named(path string, rules map[string]string, name string) Rule {
+	if tag, ok := rules[name]; ok {
+		return d.rule(path, tag)
+	}
+	return d.rule(path, rules["*"])
+}
+
+func (d *httpDump) headers(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		canonical := http.CanonicalHeaderKey(name)
+		path := "Header[" + canonical + "]"
+		kept := make([]string, len(values))
+		for i, value := range values {
+			switch {
+			case d.headerDenied(canonical):
+				kept[i] = d.transform(path, value, Rule{})
+			case canonical == "Cookie":
+				kept[i] = d.cookies(value)
+			case canonical == "Set-Cookie":
+				kept[i] = d.setCookie(value)
+			case canonical == "Referer" || canonical == "Location":
+				kept[i] = d.url(path, value)
+			default:
+				kept[i] = value
+			}
+		}
+		redacted[name] = kept
+	}
+	return redacted
+}
+
+func (d *httpDump) headerDenied(name string) bool {
+	if containsHeader(d.policy.DenyHeaders, name) {
+		return true
+	}
+	if name == "Cookie" || name == "Set-Cookie" {
+		return false
+	}
+	if containsHeader(d.policy.AllowHeaders, name) {
+		return false
+	}
+	return len(d.policy.AllowHeaders) > 0 || containsHeader(SensitiveHeaders, name)
+}
+
+func containsHeader(names []string, name string) bool {
+	for _, n := range names {
+		if http.CanonicalHeaderKey(n) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cookie redacts the value in a name=value pair.
+func (d *httpDump) cookie(pair string) string {
+	pair = strings.TrimSpace(pair)
+	name, value, ok := strings.Cut(pair, "=")
+	if !ok {
+		return pair
+	}
+	for _, allowed := range d.policy.AllowCookies {
+		if allowed == name {
+			return pair
+		}
+	}
+	path := "Cookie[" + name + "]"
+	return name + "=" + d.transform(path, value, d.rule(path, d.policy.CookieRule))
+}
+
+// cookies redacts the values of a Cookie header.
+func (d *httpDump) cookies(header string) string {
+	pairs := strings.Split(header, ";")
+	for i, pair := range pairs {
+		pairs[i] = d.cookie(pair)
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// setCookie redacts the value of a Set-Cookie header, keeping its attributes.
+func (d *httpDump) setCookie(header string) string {
+	pair, attrs, found := strings.Cut(header, ";")
+	if !found {
+		return d.cookie(pair)
+	}
+	return d.cookie(pair) + ";" + attrs
+}
+
+// url redacts the query of the URL in the header at path, which is redacted
+// as a whole if it does not parse.
+func (d *httpDump) url(path, value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
+		return d.transform(path, value, Rule{})
+	}
+	if u.RawQuery == "" {
+		return value
+	}
+	u.RawQuery = d.query(u.Query())
+	return u.String()
+}
+
+// query redacts the values of query or form parameters. url.Values.Encode
+// sorts them by name.
+func (d *httpDump) query(values url.Values) string {
+	return d.values("Query", d.policy.Query, values).Encode()
+}
+
+func (d *httpDump) values(kind string, rules map[string]string, values url.Values) url.Values {
+	redacted := make(url.Values, len(values))
+	for name, vals := range values {
+		path := kind + "[" + name + "]"
+		rule := d.named(path, rules, name)
+		for _, value := range vals {
+			redacted[name] = append(redacted[name], d.transform(path, value, rule))
+		}
+	}
+	return redacted
+}
+
+// body redacts a body by its Content-Type. Bodies that are cut off,
+// compressed or of other types are replaced with the placeholder.
+func (d *httpDump) body(header http.Header, body []byte, complete bool) []byte {
+	if len(body) == 0 && complete {
+		return nil
+	}
+	if d.policy.maxBody() < 0 {
+		return nil
+	}
+	if !complete || header.Get("Content-Encoding") != "" && header.Get("Content-Encoding") != "identity" {
+		return []byte(d.r.placeholder)
+	}
+	return d.content("Body", header.Get("Content-Type"), body, Rule{})
+}
+
+// content redacts data of contentType at path, with rule if it is not JSON, a
+// form or multipart.
+func (d *httpDump) content(path, contentType string, data []byte, rule Rule) []byte {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return d.json(path, data)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return []byte(d.r.placeholder)
+		}
+		return []byte(d.values("Form", d.policy.Form, values).Encode())
+	case strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "":
+		return d.multipart(path, data, params["boundary"])
+	default:
+		return []byte(d.transform(path, string(data), rule))
+	}
+}
+
+// json redacts a JSON body with policy.JSON, or every value in it with the
+// default rule.
+func (d *httpDump) json(path string, data []byte) []byte {
+	if d.policy.JSON != nil {
+		redacted, err := d.policy.JSON(data)
+		if err != nil {
+			d.errs = append(d.errs, &FieldError{Path: path, Type: stringType, Err: err})
+			return []byte(d.r.placeholder)
+		}
+		return redacted
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []byte(d.r.placeholder)
+	}
+	redacted, err := d.r.JSON(doc)
+	if err != nil {
+		d.errs = append(d.errs, err)
+		return []byte(d.r.placeholder)
+	}
+	return redacted
+}
+
+// multipart redacts the parts of a multipart body with the Form rule for their
+// field names, or by their own Content-Type if that is JSON, a form or
+// multipart. Part headers are kept.
+func (d *httpDump) multipart(path string, data []byte, boundary string) []byte {
+	var buf bytes.Buffer
+	reader := multipart.NewReader(bytes.NewReader(data), boundary)
+	writer := multipart.NewWriter(&buf)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return []byte(d.r.placeholder)
+	}
+
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return []byte(d.r.placeholder)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return []byte(d.r.placeholder)
+		}
+
+		name := part.FormName()
+		partPath := "Form[" + name + "]"
+		redacted := d.content(partPath, part.Header.Get("Content-Type"), content, d.named(partPath, d.policy.Form, name))
+
+		w, err := writer.CreatePart(textproto.MIMEHeader(part.Header))
+		if err != nil {
+			return []byte(d.r.placeholder)
+		}
+		if _, err := w.Write(redacted); err != nil {
+			return []byte(d.r.placeholder)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return []byte(d.r.placeholder)
+	}
+	return buf.Bytes()
+}
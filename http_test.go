@@ -0,0 +1,294 @@
+package redact_test
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/samkreter/redact/jsonpath"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/login?user=alice&token=t-123&page=2", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", "req-1")
+	req.Header.Set("Cookie", "session=s-1; theme=dark")
+	return req
+}
+
+func TestDumpRequest(t *testing.T) {
+	t.Run("Should redact headers, cookies, the query and the body", func(t *testing.T) {
+		req := newTestRequest(`{"user": "alice", "password": "hunter2", "age": 42}`)
+		policy := redact.HTTPPolicy{
+			AllowCookies: []string{"theme"},
+			Query:        map[string]string{"user": "snapshot", "page": "snapshot"},
+		}
+
+		dump, err := redact.DumpRequest(req, policy)
+		assert.NoError(t, err)
+		assert.Contains(t, string(dump), "POST /login?page=2&token=NONSNAPSHOT&user=alice HTTP/1.1", "should redact the query")
+		assert.Contains(t, string(dump), "Authorization: NONSNAPSHOT", "should redact sensitive headers")
+		assert.Contains(t, string(dump), "X-Request-Id: req-1", "should keep other headers")
+		assert.Contains(t, string(dump), "Cookie: session=NONSNAPSHOT; theme=dark", "should redact cookie values")
+		assert.Contains(t, string(dump), `{"age":0,"password":"NONSNAPSHOT","user":"NONSNAPSHOT"}`, "should redact every JSON value by default")
+		assert.NotContains(t, string(dump), "hunter2")
+
+		body, err := io.ReadAll(req.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"user": "alice", "password": "hunter2", "age": 42}`, string(body), "should not consume the body")
+	})
+
+	t.Run("Should apply header allow and deny lists", func(t *testing.T) {
+		req := newTestRequest("")
+		req.Header.Set("Accept", "text/plain")
+
+		dump, err := redact.DumpRequest(req, redact.HTTPPolicy{
+			AllowHeaders: []string{"accept", "authorization", "x-request-id"},
+			DenyHeaders:  []string{"X-Request-Id", "Cookie"},
+		})
+		assert.NoError(t, err)
+		assert.Contains(t, string(dump), "Accept: text/plain")
+		assert.Contains(t, string(dump), "Authorization: Bearer secret", "should allow sensitive headers explicitly")
+		assert.Contains(t, string(dump), "Content-Type: NONSNAPSHOT", "should redact headers that are not allowed")
+		assert.Contains(t, string(dump), "X-Request-Id: NONSNAPSHOT", "should prefer the deny list")
+		assert.Contains(t, string(dump), "Cookie: NONSNAPSHOT", "should deny cookie headers")
+	})
+
+	t.Run("Should redact JSON bodies with path rules", func(t *testing.T) {
+		req := newTestRequest(`{"user": "alice", "password": "hunter2"}`)
+		paths := jsonpath.MustNew([]string{"$.password: redact"})
+
+		dump, err := redact.DumpRequest(req, redact.HTTPPolicy{JSON: paths.Redact})
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasSuffix(dump, []byte(`{"user":"alice","password":"NONSNAPSHOT"}`)), "should use the JSON redactor")
+	})
+
+	t.Run("Should redact form and multipart bodies", func(t *testing.T) {
+		form := newTestRequest("card=4111111111111111&name=alice")
+		form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		dump, err := redact.DumpRequest(form, redact.HTTPPolicy{Form: map[string]string{"card": "last4", "*": "snapshot"}})
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasSuffix(dump, []byte("card=%2A%2A%2A%2A%2A%2A%2A%2A%2A%2A%2A%2A1111&name=alice")), "should redact form fields")
+
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		assert.NoError(t, writer.WriteField("name", "alice"))
+		file, err := writer.CreateFormFile("upload", "secret.txt")
+		assert.NoError(t, err)
+		_, err = file.Write([]byte("top secret"))
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+
+		upload := newTestRequest(body.String())
+		upload.Header.Set("Content-Type", writer.FormDataContentType())
+
+		dump, err = redact.DumpRequest(upload, redact.HTTPPolicy{Form: map[string]string{"name": "snapshot"}})
+		assert.NoError(t, err)
+		assert.Contains(t, string(dump), "alice", "should keep allowed fields")
+		assert.Contains(t, string(dump), `filename="secret.txt"`, "should keep part headers")
+		assert.NotContains(t, string(dump), "top secret", "should redact files")
+
+		uploaded, err := io.ReadAll(upload.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body.String(), string(uploaded), "should not consume the body")
+	})
+
+	t.Run("Should not parse bodies that are cut off", func(t *testing.T) {
+		req := newTestRequest(`{"password": "hunter2"}`)
+
+		dump, err := redact.DumpRequest(req, redact.HTTPPolicy{MaxBody: 8})
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasSuffix(dump, []byte("\r\n\r\nNONSNAPSHOT")), "should dump the placeholder")
+
+		body, _ := io.ReadAll(req.Body)
+		assert.Equal(t, `{"password": "hunter2"}`, string(body), "should put back what was read")
+	})
+
+	t.Run("Should report rules that do not parse", func(t *testing.T) {
+		dump, err := redact.DumpRequest(newTestRequest(""), redact.HTTPPolicy{Query: map[string]string{"*": "mask("}})
+		assert.Error(t, err)
+		assert.Contains(t, string(dump), "user=NONSNAPSHOT", "should still dump safely")
+	})
+}
+
+func TestDumpTransport(t *testing.T) {
+	t.Run("Should dump requests and responses of a client", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s-2", Path: "/"})
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"echo": ` + string(body) + `}`))
+		}))
+		defer server.Close()
+
+		var dumps []string
+		client := &http.Client{Transport: redact.NewTransport(nil, redact.HTTPPolicy{}, func(dump []byte, err error) {
+			assert.NoError(t, err)
+			dumps = append(dumps, string(dump))
+		})}
+
+		resp, err := client.Post(server.URL+"/echo?token=t-1", "application/json", strings.NewReader(`"hunter2"`))
+		assert.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, resp.Body.Close())
+
+		assert.Equal(t, `{"echo": "hunter2"}`, string(body), "should send and return the real bodies")
+		assert.Len(t, dumps, 2)
+		assert.Contains(t, dumps[0], "token=NONSNAPSHOT")
+		assert.Contains(t, dumps[1], "Set-Cookie: session=NONSNAPSHOT; Path=/", "should keep cookie attributes")
+		assert.Contains(t, dumps[1], `{"echo":"NONSNAPSHOT"}`)
+		assert.NotContains(t, strings.Join(dumps, ""), "hunter2")
+	})
+
+	t.Run("Should redact the query of redirects", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/old" {
+				http.Redirect(w, req, "/new?token=t-2", http.StatusFound)
+			}
+		}))
+		defer server.Close()
+
+		var dumps []string
+		client := &http.Client{Transport: redact.NewTransport(nil, redact.HTTPPolicy{}, func(dump []byte, err error) {
+			assert.NoError(t, err)
+			dumps = append(dumps, string(dump))
+		})}
+
+		resp, err := client.Get(server.URL + "/old?token=t-1")
+		assert.NoError(t, err)
+		assert.NoError(t, resp.Body.Close())
+
+		assert.Len(t, dumps, 4)
+		assert.Contains(t, dumps[1], "Location: /new?token=NONSNAPSHOT", "should redact the Location query")
+		assert.Contains(t, dumps[2], "GET /new?token=NONSNAPSHOT HTTP/1.1", "should dump redirects as HTTP/1.1")
+		assert.Contains(t, dumps[2], "/old?token=NONSNAPSHOT", "should redact the Referer query")
+		assert.NotContains(t, strings.Join(dumps, ""), "t-1")
+		assert.NotContains(t, strings.Join(dumps, ""), "t-2")
+	})
+}
+
+func TestDumpTransportStreaming(t *testing.T) {
+	t.Run("Should not wait for streamed bodies", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"token": `))
+			w.(http.Flusher).Flush()
+			<-release
+			_, _ = w.Write([]byte(`"t-1"}`))
+		}))
+		defer server.Close()
+
+		dumps := make(chan string, 2)
+		client := &http.Client{Transport: redact.NewTransport(nil, redact.HTTPPolicy{}, func(dump []byte, err error) {
+			assert.NoError(t, err)
+			dumps <- string(dump)
+		})}
+
+		resp, err := client.Get(server.URL)
+		assert.NoError(t, err, "should return before the body is complete")
+		assert.Contains(t, <-dumps, "GET / HTTP/1.1")
+		assert.Len(t, dumps, 0, "should dump the response once the body is read")
+
+		close(release)
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, resp.Body.Close())
+		assert.Equal(t, `{"token": "t-1"}`, string(body), "should pass the real body on")
+		assert.Contains(t, <-dumps, `{"token":"NONSNAPSHOT"}`)
+	})
+
+	t.Run("Should dump bodies closed early as the placeholder", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write([]byte("hunter2"))
+		}))
+		defer server.Close()
+
+		var dumps []string
+		client := &http.Client{Transport: redact.NewTransport(nil, redact.HTTPPolicy{}, func(dump []byte, err error) {
+			dumps = append(dumps, string(dump))
+		})}
+
+		resp, err := client.Get(server.URL)
+		assert.NoError(t, err)
+		assert.NoError(t, resp.Body.Close())
+		assert.Len(t, dumps, 2)
+		assert.True(t, strings.HasSuffix(dumps[1], "\r\n\r\nNONSNAPSHOT"), "should not dump a partial body")
+	})
+
+	t.Run("Should allow closing the body while it is read", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write([]byte("hunter2"))
+			w.(http.Flusher).Flush()
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		dumps := make(chan string, 2)
+		client := &http.Client{Transport: redact.NewTransport(nil, redact.HTTPPolicy{}, func(dump []byte, err error) {
+			dumps <- string(dump)
+		})}
+
+		resp, err := client.Get(server.URL)
+		assert.NoError(t, err)
+		<-dumps
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = io.ReadAll(resp.Body)
+		}()
+		assert.NoError(t, resp.Body.Close())
+		<-done
+		assert.True(t, strings.HasSuffix(<-dumps, "\r\n\r\nNONSNAPSHOT"), "should dump the closed body once")
+	})
+
+	t.Run("Should allow a nil DumpFunc", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+		defer server.Close()
+
+		client := &http.Client{Transport: redact.NewTransport(nil, redact.HTTPPolicy{}, nil)}
+		resp, err := client.Get(server.URL)
+		assert.NoError(t, err)
+		assert.NoError(t, resp.Body.Close())
+
+		handler := redact.Middleware(redact.HTTPPolicy{}, nil)(http.NotFoundHandler())
+		assert.NotPanics(t, func() { handler.ServeHTTP(httptest.NewRecorder(), newTestRequest("")) })
+	})
+}
+
+func TestDumpMiddleware(t *testing.T) {
+	t.Run("Should dump requests and responses of a server", func(t *testing.T) {
+		var dumps []string
+		middleware := redact.Middleware(redact.HTTPPolicy{}, func(dump []byte, err error) {
+			assert.NoError(t, err)
+			dumps = append(dumps, string(dump))
+		})
+		handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write(body)
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newTestRequest(`{"password": "hunter2"}`))
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, `{"password": "hunter2"}`, rec.Body.String(), "should pass the real body on")
+		assert.Len(t, dumps, 2)
+		assert.Contains(t, dumps[1], "HTTP/1.1 201 Created")
+		assert.Contains(t, dumps[1], `{"password":"NONSNAPSHOT"}`)
+	})
+}
@@ -0,0 +1,94 @@
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestLocation struct {
+	Street string
+}
+
+type TestContact struct {
+	Name    string
+	Email   string `redact:"email"`
+	Phone   string `redact:"reset"`
+	Notes   string `redact:"inherit,omitempty"`
+	Visits  int
+	Address *TestLocation
+}
+
+type TestInheritStruct struct {
+	Public   TestContact            `redact:"snapshot"`
+	Masked   *TestContact           `redact:"mask"`
+	List     []TestContact          `redact:"snapshot"`
+	ByName   map[string]TestContact `redact:"snapshot"`
+	Untagged TestContact
+}
+
+func TestInheritance(t *testing.T) {
+	newContact := func() TestContact {
+		return TestContact{
+			Name:    "alice",
+			Email:   "alice@example.com",
+			Phone:   "555-0100",
+			Visits:  3,
+			Address: &TestLocation{Street: "1 Main St"},
+		}
+	}
+
+	t.Run("Should pass the rule of a field down to untagged fields beneath it", func(t *testing.T) {
+		masked := newContact()
+		tStruct := &TestInheritStruct{
+			Public:   newContact(),
+			Masked:   &masked,
+			List:     []TestContact{newContact()},
+			ByName:   map[string]TestContact{"alice": newContact()},
+			Untagged: newContact(),
+		}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		for name, contact := range map[string]TestContact{
+			"struct": tStruct.Public,
+			"slice":  tStruct.List[0],
+			"map":    tStruct.ByName["alice"],
+		} {
+			assert.Equal(t, "alice", contact.Name, "should inherit snapshot in %s", name)
+			assert.Equal(t, 3, contact.Visits, "should inherit snapshot in %s", name)
+			assert.Equal(t, "1 Main St", contact.Address.Street, "should inherit snapshot through pointers in %s", name)
+			assert.Equal(t, "*****@example.com", contact.Email, "should let inner tags override in %s", name)
+			assert.Equal(t, redact.RedactStrConst, contact.Phone, "should reset to the default rule in %s", name)
+		}
+
+		assert.Equal(t, "*****", tStruct.Masked.Name, "should inherit mask")
+		assert.Equal(t, "*********", tStruct.Masked.Address.Street, "should inherit mask through nested structs")
+		assert.Equal(t, 0, tStruct.Masked.Visits, "should use the numeric placeholder for inherited string rules")
+
+		assert.Equal(t, redact.RedactStrConst, tStruct.Untagged.Name, "should use the default rule without a parent rule")
+		assert.Equal(t, redact.RedactStrConst, tStruct.Untagged.Address.Street, "should use the default rule without a parent rule")
+	})
+
+	t.Run("Should add flags to the inherited rule", func(t *testing.T) {
+		masked := newContact()
+		masked.Notes = "vip"
+		tStruct := &TestInheritStruct{Masked: &masked, Untagged: newContact()}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+		assert.Equal(t, "***", tStruct.Masked.Notes, "should inherit the rule")
+		assert.Equal(t, "", tStruct.Untagged.Notes, "should keep the omitempty flag")
+	})
+
+	t.Run("Should not report inherited rules for other kinds in strict mode", func(t *testing.T) {
+		masked := newContact()
+		tStruct := &TestInheritStruct{Masked: &masked}
+
+		err := redact.New(redact.WithStrict()).Snapshot(tStruct)
+		assert.NoError(t, err, "should not report inherited rules")
+		assert.Equal(t, 0, tStruct.Masked.Visits, "should still redact numbers")
+	})
+}
@@ -0,0 +1,78 @@
+package redact_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestInterfaceStruct struct {
+	Metadata interface{}
+	Payload  map[string]interface{}
+	Items    []interface{}
+	Public   interface{} `redact:"snapshot"`
+	Masked   interface{} `redact:"last4"`
+	Empty    interface{}
+}
+
+func TestInterfaceRedaction(t *testing.T) {
+	t.Run("Should redact dynamic values of interfaces", func(t *testing.T) {
+		tStruct := &TestInterfaceStruct{
+			Metadata: TestStruct{NonSnapshot: nonSnapshotVal, SnapshotStr: snapshotVal},
+			Items:    []interface{}{nonSnapshotVal, 42, &TestStruct{NonSnapshot: nonSnapshotVal}},
+			Public:   snapshotVal,
+			Masked:   "4111111111111111",
+		}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		metadata := tStruct.Metadata.(TestStruct)
+		assert.Equal(t, redact.RedactStrConst, metadata.NonSnapshot, "should redact structs in interfaces")
+		assert.Equal(t, snapshotVal, metadata.SnapshotStr, "should contain snapshot value")
+		assert.Equal(t, redact.RedactStrConst, tStruct.Items[0], "should redact strings in interfaces")
+		assert.Equal(t, 0, tStruct.Items[1], "should redact numbers in interfaces")
+		assert.Equal(t, redact.RedactStrConst, tStruct.Items[2].(*TestStruct).NonSnapshot, "should redact pointers in interfaces")
+		assert.Equal(t, snapshotVal, tStruct.Public, "should contain snapshot value")
+		assert.Equal(t, "************1111", tStruct.Masked, "should apply tag to dynamic value")
+		assert.Nil(t, tStruct.Empty, "should keep nil interfaces")
+	})
+
+	t.Run("Should redact decoded JSON", func(t *testing.T) {
+		tStruct := &TestInterfaceStruct{}
+		err := json.Unmarshal([]byte(`{
+			"Payload": {
+				"user": {"email": "gopher@example.com", "tags": ["admin", "beta"]},
+				"amount": 12.5,
+				"verified": true
+			}
+		}`), tStruct)
+		assert.NoError(t, err, "should decode JSON")
+
+		err = redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		user := tStruct.Payload["user"].(map[string]interface{})
+		assert.Equal(t, redact.RedactStrConst, user["email"], "should redact nested maps")
+		assert.Equal(t, []interface{}{redact.RedactStrConst, redact.RedactStrConst}, user["tags"], "should redact nested slices")
+		assert.Equal(t, 0.0, tStruct.Payload["amount"], "should redact numbers")
+		assert.Equal(t, false, tStruct.Payload["verified"], "should redact bools")
+	})
+
+	t.Run("Should not modify the original when copying", func(t *testing.T) {
+		inner := &TestStruct{NonSnapshot: nonSnapshotVal}
+		tStruct := TestInterfaceStruct{
+			Payload: map[string]interface{}{"inner": inner, "list": []interface{}{nonSnapshotVal}},
+		}
+
+		redacted, err := redact.Redacted(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, redact.RedactStrConst, redacted.Payload["inner"].(*TestStruct).NonSnapshot, "should redact the copy")
+		assert.Equal(t, redact.RedactStrConst, redacted.Payload["list"].([]interface{})[0], "should redact the copy")
+		assert.Equal(t, nonSnapshotVal, inner.NonSnapshot, "should not modify the original")
+		assert.Equal(t, nonSnapshotVal, tStruct.Payload["list"].([]interface{})[0], "should not modify the original")
+	})
+}
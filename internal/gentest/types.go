@@ -0,0 +1,56 @@
+// Package gentest holds types with methods generated by cmd/redactgen, to test
+// that the generated code redacts like the reflection walker.
+package gentest
+
+import (
+	"time"
+)
+
+//go:generate go run ../../cmd/redactgen -type User,Address,Account
+
+type Token string
+
+type User struct {
+	ID        string `redact:"snapshot"`
+	Name      string
+	Email     string `redact:"email"`
+	Token     Token  `redact:"mask(keep=4)"`
+	Age       int
+	Score     float64 `redact:"round(10)"`
+	Admin     bool
+	Timeout   time.Duration `redact:"snapshot"`
+	Created   time.Time
+	Address   Address
+	Billing   *Address
+	Shipping  Address `redact:"snapshot"`
+	Previous  []Address
+	Tags      []string
+	Labels    map[string]string `redact:"values=snapshot"`
+	Extra     interface{}
+	Accounts  []*Account
+	Manager   *User
+	Note      *string `redact:"truncate(4)"`
+	Secret    []byte
+	Broken    string `redact:"truncate(len=x),omitempty"`
+	internal  string
+	Callbacks []func()
+	audit
+}
+
+type audit struct {
+	Reviewer string
+	Reviewed *time.Time
+}
+
+type Address struct {
+	Street  string
+	City    string `redact:"snapshot"`
+	Country string `redact:"reset"`
+	Zip     int    `redact:"inherit"`
+}
+
+type Account struct {
+	Number string `redact:"last4"`
+	Owner  *User
+	Address
+}
@@ -0,0 +1,131 @@
+package gentest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+func newUser() *User {
+	note := "a note about alice"
+	reviewed := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	manager := &User{Name: "Bob", Email: "bob@example.com"}
+	billing := &Address{Street: "2 Side St", City: "Springfield", Country: "US", Zip: 12345}
+	u := &User{
+		ID:       "user-1",
+		Name:     "Alice",
+		Email:    "alice@example.com",
+		Token:    "tok_1234567890",
+		Age:      42,
+		Score:    87.6,
+		Admin:    true,
+		Timeout:  time.Minute,
+		Created:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Address:  Address{Street: "1 Main St", City: "Springfield", Country: "US", Zip: 12345},
+		Billing:  billing,
+		Shipping: Address{Street: "3 Back St", City: "Shelbyville", Country: "US", Zip: 54321},
+		Previous: []Address{{Street: "4 Old St", City: "Ogdenville"}},
+		Tags:     []string{"vip", "beta"},
+		Labels:   map[string]string{"team": "payments"},
+		Extra:    Address{Street: "5 Any St"},
+		Manager:  manager,
+		Note:     &note,
+		Secret:   []byte("hunter2"),
+		Broken:   "broken",
+		internal: "internal",
+		audit:    audit{Reviewer: "carol", Reviewed: &reviewed},
+	}
+	u.Accounts = []*Account{
+		{Number: "4111111111111111", Owner: u, Address: Address{Street: "6 Bank St"}},
+		{Number: "5500000000000004", Owner: manager, Address: *billing},
+	}
+	return u
+}
+
+func TestGenerated(t *testing.T) {
+	t.Run("Should redact like the reflection walker", func(t *testing.T) {
+		generated, reflected := newUser(), newUser()
+
+		genErr := generated.RedactSnapshot()
+		refErr := redact.New(redact.WithoutGenerated()).Snapshot(reflected)
+
+		assert.Error(t, genErr, "should report the broken rule")
+		assert.Equal(t, refErr, genErr, "should report the same errors")
+		assert.Equal(t, reflected, generated, "should redact the same way")
+
+		assert.Equal(t, "user-1", generated.ID, "should contain snapshot value")
+		assert.Equal(t, "*****@example.com", generated.Email, "should apply rules")
+		assert.Equal(t, redact.RedactStrConst, generated.Address.Street, "should redact generated fields")
+		assert.Equal(t, "3 Back St", generated.Shipping.Street, "should inherit rules")
+		assert.Equal(t, redact.RedactStrConst, generated.Shipping.Country, "should reset rules")
+		assert.Equal(t, "internal", generated.internal, "should leave unexported fields alone")
+		assert.Equal(t, redact.RedactStrConst, generated.Reviewer, "should redact promoted fields")
+	})
+
+	t.Run("Should copy like the reflection walker", func(t *testing.T) {
+		original := newUser()
+
+		generated, genErr := original.RedactedCopy()
+		reflected, refErr := redact.New(redact.WithoutGenerated()).Copy(original)
+
+		assert.Error(t, genErr, "should report the broken rule")
+		assert.Equal(t, refErr, genErr, "should report the same errors")
+		assert.Nil(t, reflected, "should not return a copy on errors")
+		assert.Nil(t, generated, "should not return a copy on errors")
+		assert.Equal(t, newUser(), original, "should not modify the original")
+	})
+
+	t.Run("Should redact like the reflection walker without errors", func(t *testing.T) {
+		generated, reflected := newUser(), newUser()
+		generated.Broken, reflected.Broken = "", ""
+
+		cp, err := generated.RedactedCopy()
+		assert.NoError(t, err, "should not fail to copy")
+		assert.NoError(t, generated.RedactSnapshot(), "should not fail to redact")
+		assert.NoError(t, redact.New(redact.WithoutGenerated()).Snapshot(reflected), "should not fail to redact")
+		assert.Equal(t, reflected, generated, "should redact the same way")
+		assert.Equal(t, reflected, cp, "should copy the same way")
+		assert.Same(t, cp, cp.Accounts[0].Owner, "should keep cycles in the copy")
+		assert.Same(t, cp.Manager, cp.Accounts[1].Owner, "should keep shared pointers in the copy")
+	})
+}
+
+func BenchmarkSnapshot(b *testing.B) {
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			u := newUser()
+			u.Broken = ""
+			_ = u.RedactSnapshot()
+		}
+	})
+	b.Run("reflection", func(b *testing.B) {
+		r := redact.New(redact.WithoutGenerated())
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			u := newUser()
+			u.Broken = ""
+			_ = r.Snapshot(u)
+		}
+	})
+}
+
+func BenchmarkCopy(b *testing.B) {
+	u := newUser()
+	u.Broken = ""
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = u.RedactedCopy()
+		}
+	})
+	b.Run("reflection", func(b *testing.B) {
+		r := redact.New(redact.WithoutGenerated())
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = r.Copy(u)
+		}
+	})
+}
@@ -0,0 +1,229 @@
+// Code generated by "redactgen -type User,Address,Account"; DO NOT EDIT.
+
+package gentest
+
+import "github.com/samkreter/redact"
+
+var redactRulesUser = [...]redact.Rule{
+	redact.MustParseRule("snapshot"),
+	{},
+	redact.MustParseRule("email"),
+	redact.MustParseRule("mask(keep=4)"),
+	{},
+	redact.MustParseRule("round(10)"),
+	{},
+	redact.MustParseRule("snapshot"),
+	{},
+	{},
+	{},
+	redact.MustParseRule("snapshot"),
+	{},
+	{},
+	redact.MustParseRule("values=snapshot"),
+	{},
+	{},
+	{},
+	redact.MustParseRule("truncate(4)"),
+	{},
+	redact.MustParseRule("truncate(len=x),omitempty"),
+	{},
+	{},
+}
+
+// RedactSnapshot redacts v in place like redact.Snapshot(v).
+func (v *User) RedactSnapshot() error {
+	return redact.SnapshotGenerated(v)
+}
+
+// RedactedCopy returns a redacted deep copy of v like redact.Redacted(v).
+func (v *User) RedactedCopy() (*User, error) {
+	return redact.CopyGenerated(v)
+}
+
+// CloneWith returns a deep copy of v. It is called by redact.Copy.
+func (v *User) CloneWith(c *redact.Clone) interface{} {
+	return redact.ClonePtr(c, v)
+}
+
+// RedactWith redacts the fields of v, which is held by a field tagged with
+// rule. It is called by the redact walker.
+func (v *User) RedactWith(g *redact.Gen, rule redact.Rule) {
+	g.Field("ID")
+	redact.String(g, &v.ID, g.Inherit(redactRulesUser[0], rule))
+	g.Pop()
+	g.Field("Name")
+	redact.String(g, &v.Name, g.Inherit(redactRulesUser[1], rule))
+	g.Pop()
+	g.Field("Email")
+	redact.String(g, &v.Email, g.Inherit(redactRulesUser[2], rule))
+	g.Pop()
+	g.Field("Token")
+	redact.String(g, &v.Token, g.Inherit(redactRulesUser[3], rule))
+	g.Pop()
+	g.Field("Age")
+	redact.Number(g, &v.Age, g.Inherit(redactRulesUser[4], rule))
+	g.Pop()
+	g.Field("Score")
+	redact.Number(g, &v.Score, g.Inherit(redactRulesUser[5], rule))
+	g.Pop()
+	g.Field("Admin")
+	redact.Bool(g, &v.Admin, g.Inherit(redactRulesUser[6], rule))
+	g.Pop()
+	g.Field("Timeout")
+	redact.Number(g, &v.Timeout, g.Inherit(redactRulesUser[7], rule))
+	g.Pop()
+	g.Field("Created")
+	g.Value(&v.Created, g.Inherit(redactRulesUser[8], rule))
+	g.Pop()
+	g.Field("Address")
+	v.Address.RedactWith(g, g.Inherit(redactRulesUser[9], rule))
+	g.Pop()
+	g.Field("Billing")
+	if r := g.Inherit(redactRulesUser[10], rule); redact.Enter(g, v.Billing, r) {
+		v.Billing.RedactWith(g, r)
+	}
+	g.Pop()
+	g.Field("Shipping")
+	v.Shipping.RedactWith(g, g.Inherit(redactRulesUser[11], rule))
+	g.Pop()
+	g.Field("Previous")
+	g.Value(&v.Previous, g.Inherit(redactRulesUser[12], rule))
+	g.Pop()
+	g.Field("Tags")
+	g.Value(&v.Tags, g.Inherit(redactRulesUser[13], rule))
+	g.Pop()
+	g.Field("Labels")
+	g.Value(&v.Labels, g.Inherit(redactRulesUser[14], rule))
+	g.Pop()
+	g.Field("Extra")
+	g.Value(&v.Extra, g.Inherit(redactRulesUser[15], rule))
+	g.Pop()
+	g.Field("Accounts")
+	g.Value(&v.Accounts, g.Inherit(redactRulesUser[16], rule))
+	g.Pop()
+	g.Field("Manager")
+	if r := g.Inherit(redactRulesUser[17], rule); redact.Enter(g, v.Manager, r) {
+		v.Manager.RedactWith(g, r)
+	}
+	g.Pop()
+	g.Field("Note")
+	if r := g.Inherit(redactRulesUser[18], rule); redact.Enter(g, v.Note, r) {
+		redact.String(g, v.Note, r)
+	}
+	g.Pop()
+	g.Field("Secret")
+	g.Value(&v.Secret, g.Inherit(redactRulesUser[19], rule))
+	g.Pop()
+	g.Field("Broken")
+	redact.String(g, &v.Broken, g.Inherit(redactRulesUser[20], rule))
+	g.Pop()
+	g.Field("Callbacks")
+	g.Value(&v.Callbacks, g.Inherit(redactRulesUser[21], rule))
+	g.Pop()
+	g.Field("audit")
+	g.Value(&v.audit, g.Inherit(redactRulesUser[22], rule))
+	g.Pop()
+}
+
+// CloneInto sets *cp to a deep copy of v. It is called by redact.Copy.
+func (v *User) CloneInto(c *redact.Clone, cp *User) {
+	*cp = *v
+	cp.Billing = redact.ClonePtr(c, v.Billing)
+	c.Value(&cp.Previous)
+	c.Value(&cp.Tags)
+	c.Value(&cp.Labels)
+	c.Value(&cp.Extra)
+	c.Value(&cp.Accounts)
+	cp.Manager = redact.ClonePtr(c, v.Manager)
+	cp.Note = redact.ClonePtrTo(c, v.Note)
+	c.Value(&cp.Secret)
+	c.Value(&cp.Callbacks)
+	c.Value(&cp.audit)
+}
+
+var redactRulesAddress = [...]redact.Rule{
+	{},
+	redact.MustParseRule("snapshot"),
+	redact.MustParseRule("reset"),
+	redact.MustParseRule("inherit"),
+}
+
+// RedactSnapshot redacts v in place like redact.Snapshot(v).
+func (v *Address) RedactSnapshot() error {
+	return redact.SnapshotGenerated(v)
+}
+
+// RedactedCopy returns a redacted deep copy of v like redact.Redacted(v).
+func (v *Address) RedactedCopy() (*Address, error) {
+	return redact.CopyGenerated(v)
+}
+
+// CloneWith returns a deep copy of v. It is called by redact.Copy.
+func (v *Address) CloneWith(c *redact.Clone) interface{} {
+	return redact.ClonePtr(c, v)
+}
+
+// RedactWith redacts the fields of v, which is held by a field tagged with
+// rule. It is called by the redact walker.
+func (v *Address) RedactWith(g *redact.Gen, rule redact.Rule) {
+	g.Field("Street")
+	redact.String(g, &v.Street, g.Inherit(redactRulesAddress[0], rule))
+	g.Pop()
+	g.Field("City")
+	redact.String(g, &v.City, g.Inherit(redactRulesAddress[1], rule))
+	g.Pop()
+	g.Field("Country")
+	redact.String(g, &v.Country, g.Inherit(redactRulesAddress[2], rule))
+	g.Pop()
+	g.Field("Zip")
+	redact.Number(g, &v.Zip, g.Inherit(redactRulesAddress[3], rule))
+	g.Pop()
+}
+
+// CloneInto sets *cp to a deep copy of v. It is called by redact.Copy.
+func (v *Address) CloneInto(c *redact.Clone, cp *Address) {
+	*cp = *v
+}
+
+var redactRulesAccount = [...]redact.Rule{
+	redact.MustParseRule("last4"),
+	{},
+	{},
+}
+
+// RedactSnapshot redacts v in place like redact.Snapshot(v).
+func (v *Account) RedactSnapshot() error {
+	return redact.SnapshotGenerated(v)
+}
+
+// RedactedCopy returns a redacted deep copy of v like redact.Redacted(v).
+func (v *Account) RedactedCopy() (*Account, error) {
+	return redact.CopyGenerated(v)
+}
+
+// CloneWith returns a deep copy of v. It is called by redact.Copy.
+func (v *Account) CloneWith(c *redact.Clone) interface{} {
+	return redact.ClonePtr(c, v)
+}
+
+// RedactWith redacts the fields of v, which is held by a field tagged with
+// rule. It is called by the redact walker.
+func (v *Account) RedactWith(g *redact.Gen, rule redact.Rule) {
+	g.Field("Number")
+	redact.String(g, &v.Number, g.Inherit(redactRulesAccount[0], rule))
+	g.Pop()
+	g.Field("Owner")
+	if r := g.Inherit(redactRulesAccount[1], rule); redact.Enter(g, v.Owner, r) {
+		v.Owner.RedactWith(g, r)
+	}
+	g.Pop()
+	g.Field("Address")
+	v.Address.RedactWith(g, g.Inherit(redactRulesAccount[2], rule))
+	g.Pop()
+}
+
+// CloneInto sets *cp to a deep copy of v. It is called by redact.Copy.
+func (v *Account) CloneInto(c *redact.Clone, cp *Account) {
+	*cp = *v
+	cp.Owner = redact.ClonePtr(c, v.Owner)
+}
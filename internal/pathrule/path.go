@@ -0,0 +1,204 @@
+package pathrule
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPath is returned for a path that does not follow the path grammar.
+var ErrInvalidPath = errors.New("invalid path")
+
+// segment is one step of a path. It matches an object member called name, the
+// array element at index, any member or element if wildcard is set, or the
+// members whose lower case name matches the glob pattern. A recursive segment
+// may skip any number of levels before it matches.
+type segment struct {
+	name      string
+	index     int
+	isIndex   bool
+	wildcard  bool
+	pattern   string
+	recursive bool
+}
+
+// Step is one level of a concrete location in a document: an object member or
+// an array element.
+type Step struct {
+	Name    string
+	Index   int
+	IsIndex bool
+}
+
+// Key returns the Step for the object member called name.
+func Key(name string) Step {
+	return Step{Name: name}
+}
+
+// Index returns the Step for the array element at i.
+func Index(i int) Step {
+	return Step{Index: i, IsIndex: true}
+}
+
+func (s segment) matches(st Step) bool {
+	switch {
+	case s.wildcard:
+		return true
+	case s.isIndex:
+		return st.IsIndex && st.Index == s.index
+	case s.pattern != "":
+		matched, _ := path.Match(s.pattern, strings.ToLower(st.Name))
+		return !st.IsIndex && matched
+	default:
+		return !st.IsIndex && st.Name == s.name
+	}
+}
+
+// Path is a parsed path like $.cards[*].pan or $..password.
+type Path []segment
+
+// Match reports whether the path matches the location steps exactly.
+func (p Path) Match(steps []Step) bool {
+	if len(p) == 0 {
+		return len(steps) == 0
+	}
+
+	seg := p[0]
+	if !seg.recursive {
+		return len(steps) > 0 && seg.matches(steps[0]) && p[1:].Match(steps[1:])
+	}
+	for i := range steps {
+		if seg.matches(steps[i]) && p[1:].Match(steps[i+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyPattern returns a path matching the object members at any depth whose
+// name matches the glob pattern, see path.Match. Names are matched in lower
+// case, so "*password*" matches DBPassword.
+func KeyPattern(pattern string) (Path, error) {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+		return nil, fmt.Errorf("%w: bad key pattern %q", ErrInvalidPath, pattern)
+	}
+	return Path{{pattern: pattern, recursive: true}}, nil
+}
+
+// ParsePath parses the path at the start of s and returns the rest of s. A
+// path starts with $ and is followed by any number of segments:
+//
+//	.name  ['name']  ["name"]  member called name
+//	[3]                        element at index 3
+//	.*  [*]                    any member or element
+//	..name  ..*  ..[0]         the same, at any depth below
+func ParsePath(s string) (Path, string, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, s, fmt.Errorf("%w: %q does not start with $", ErrInvalidPath, s)
+	}
+
+	var p Path
+	rest := s[1:]
+	for {
+		var seg segment
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			seg.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				break
+			}
+			var err error
+			seg, rest, err = parseName(rest, seg)
+			if err != nil {
+				return nil, rest, err
+			}
+			p = append(p, seg)
+			continue
+		case strings.HasPrefix(rest, "."):
+			var err error
+			seg, rest, err = parseName(rest[1:], seg)
+			if err != nil {
+				return nil, rest, err
+			}
+			p = append(p, seg)
+			continue
+		case strings.HasPrefix(rest, "["):
+		default:
+			return p, rest, nil
+		}
+
+		var err error
+		seg, rest, err = parseBracket(rest, seg)
+		if err != nil {
+			return nil, rest, err
+		}
+		p = append(p, seg)
+	}
+}
+
+// parseName parses a member name or * after a dot.
+func parseName(s string, seg segment) (segment, string, error) {
+	if strings.HasPrefix(s, "*") {
+		seg.wildcard = true
+		return seg, s[1:], nil
+	}
+
+	end := strings.IndexFunc(s, func(c rune) bool {
+		return c == '.' || c == '[' || c == ':' || c == ' ' || c == '\t'
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	if end == 0 {
+		return seg, s, fmt.Errorf("%w: missing name at %q", ErrInvalidPath, s)
+	}
+	seg.name = s[:end]
+	return seg, s[end:], nil
+}
+
+// parseBracket parses a quoted name, an index or * in brackets.
+func parseBracket(s string, seg segment) (segment, string, error) {
+	if len(s) > 1 && (s[1] == '\'' || s[1] == '"') {
+		closing := strings.Index(s[2:], s[1:2]+"]")
+		if closing < 0 {
+			return seg, s, fmt.Errorf("%w: unterminated name at %q", ErrInvalidPath, s)
+		}
+		seg.name = s[2 : 2+closing]
+		return seg, s[2+closing+2:], nil
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return seg, s, fmt.Errorf("%w: missing ] at %q", ErrInvalidPath, s)
+	}
+
+	inner := strings.TrimSpace(s[1:end])
+	if inner == "*" {
+		seg.wildcard = true
+		return seg, s[end+1:], nil
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil || index < 0 {
+		return seg, s, fmt.Errorf("%w: %q is not an index", ErrInvalidPath, inner)
+	}
+	seg.index, seg.isIndex = index, true
+	return seg, s[end+1:], nil
+}
+
+// Format formats a location like $.cards[0].pan, for errors.
+func Format(steps []Step) string {
+	var b strings.Builder
+	b.WriteByte('$')
+	for _, st := range steps {
+		if st.IsIndex {
+			b.WriteString("[" + strconv.Itoa(st.Index) + "]")
+			continue
+		}
+		b.WriteString("." + st.Name)
+	}
+	return b.String()
+}
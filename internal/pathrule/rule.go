@@ -0,0 +1,104 @@
+// Package pathrule parses and matches the path rules shared by the jsonpath
+// and yamlpath packages, such as
+//
+//	$.cards[*].pan: last4
+//
+// A rule is a path and a redact tag separated by a colon.
+package pathrule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samkreter/redact"
+)
+
+// ErrInvalidRule is returned for a rule that is not a path followed by a colon
+// and a redact tag.
+var ErrInvalidRule = errors.New("invalid path rule")
+
+// Rule is a parsed path rule.
+type Rule struct {
+	Path Path
+	Rule redact.Rule
+}
+
+// Parse parses a rule, checking that the redactor it names is registered on
+// r. The tag "redact" stands for the default rule of r. If keyPatterns is set
+// a rule that does not start with $ is a key pattern, see KeyPattern, e.g.
+// "*password*: redact".
+func Parse(r *redact.Redactor, text string, keyPatterns bool) (Rule, error) {
+	text = strings.TrimSpace(text)
+
+	var p Path
+	var rest string
+	var err error
+	if keyPatterns && !strings.HasPrefix(text, "$") {
+		pattern, tag, ok := strings.Cut(text, ":")
+		if !ok {
+			return Rule{}, fmt.Errorf("%w: %q has no colon after the key pattern", ErrInvalidRule, text)
+		}
+		p, err = KeyPattern(strings.TrimSpace(pattern))
+		rest = ":" + tag
+	} else {
+		p, rest, err = ParsePath(text)
+	}
+	if err != nil {
+		return Rule{}, err
+	}
+
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, ":") {
+		return Rule{}, fmt.Errorf("%w: %q has no colon after the path", ErrInvalidRule, text)
+	}
+
+	rule, err := redact.ParseRule(strings.TrimSpace(rest[1:]))
+	if err != nil {
+		return Rule{}, fmt.Errorf("%w: %q: %w", ErrInvalidRule, text, err)
+	}
+	switch rule.Name {
+	case "":
+		return Rule{}, fmt.Errorf("%w: %q has no rule", ErrInvalidRule, text)
+	case "redact":
+		rule.Name = ""
+	default:
+		if err := r.CheckRule(rule); err != nil {
+			return Rule{}, fmt.Errorf("%w: %q: %w", ErrInvalidRule, text, err)
+		}
+	}
+	return Rule{Path: p, Rule: rule}, nil
+}
+
+// Rules is a list of rules, later rules taking precedence.
+type Rules []Rule
+
+// Resolve returns the rule for the value at the location steps: that of the
+// last rule whose path matches, or inherited from the enclosing value if none
+// does. "inherit" takes the inherited rule and "reset" the default rule, as
+// they do in tags.
+func (rs Rules) Resolve(steps []Step, inherited redact.Rule) redact.Rule {
+	rule := inherited
+	for _, r := range rs {
+		if r.Path.Match(steps) {
+			rule = r.Rule
+		}
+	}
+
+	switch rule.Name {
+	case "inherit":
+		return inherited
+	case "reset":
+		return redact.Rule{Flags: rule.Flags}
+	}
+	return rule
+}
+
+// Base returns the rule for values no path leads to: "snapshot", which keeps
+// them, or the default rule in allowlist mode.
+func Base(allowlist bool) redact.Rule {
+	if allowlist {
+		return redact.Rule{}
+	}
+	return redact.Rule{Name: "snapshot"}
+}
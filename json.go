@@ -0,0 +1,875 @@
+package redact
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MarshalJSON returns the JSON encoding of v redacted with the default
+// Redactor, see Redactor.JSON.
+func MarshalJSON(v interface{}) ([]byte, error) {
+	return defaultRedactor.JSON(v)
+}
+
+// JSON returns the JSON encoding of v with every value redacted by its
+// redact tag, without changing v. The output is what json.Marshal would
+// return for a redacted copy of v: json tag names, omitempty, string and "-"
+// are honoured, omitempty looking at the redacted value. Fields redacted with
+// the "omit" rule are left out altogether, as are map entries whose values
+// are.
+//
+// Values that implement json.Marshaler or encoding.TextMarshaler, or redact
+// themselves, are redacted in a copy which is then marshaled. Strings and
+// bytes that marshal themselves, such as net.IP, are marshaled as they are and
+// the resulting text is redacted.
+//
+// Like Copy, JSON returns nil and the errors if any value failed to redact.
+// It is not named MarshalJSON, which would make a Redactor a json.Marshaler.
+func (r *Redactor) JSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.encodeJSON(&buf, v, true); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// NewJSONEncoder returns a JSONEncoder writing to w that redacts with the
+// default Redactor.
+func NewJSONEncoder(w io.Writer) *JSONEncoder {
+	return defaultRedactor.NewJSONEncoder(w)
+}
+
+// NewJSONEncoder returns a JSONEncoder writing to w.
+func (r *Redactor) NewJSONEncoder(w io.Writer) *JSONEncoder {
+	return &JSONEncoder{r: r, w: w, escapeHTML: true}
+}
+
+// JSONEncoder writes redacted JSON values to an output stream, like
+// json.Encoder does for values that are not redacted.
+type JSONEncoder struct {
+	r          *Redactor
+	w          io.Writer
+	prefix     string
+	indent     string
+	escapeHTML bool
+}
+
+// Encode writes the JSON encoding of v, redacted as Redactor.JSON does,
+// followed by a newline. Nothing is written if redacting v fails.
+func (e *JSONEncoder) Encode(v interface{}) error {
+	var buf bytes.Buffer
+	if err := e.r.encodeJSON(&buf, v, e.escapeHTML); err != nil {
+		return err
+	}
+
+	if e.prefix != "" || e.indent != "" {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, buf.Bytes(), e.prefix, e.indent); err != nil {
+			return err
+		}
+		buf = indented
+	}
+	buf.WriteByte('\n')
+
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+// SetIndent makes the encoder indent its output like json.Encoder.SetIndent.
+func (e *JSONEncoder) SetIndent(prefix, indent string) {
+	e.prefix, e.indent = prefix, indent
+}
+
+// SetEscapeHTML sets whether &, < and > are escaped in strings, like
+// json.Encoder.SetEscapeHTML. They are by default.
+func (e *JSONEncoder) SetEscapeHTML(on bool) {
+	e.escapeHTML = on
+}
+
+// jsonState is the state of encoding one value.
+type jsonState struct {
+	*walker
+	buf        *bytes.Buffer
+	escapeHTML bool
+
+	// ancestors holds the pointers, maps and slices on the way to the value
+	// being encoded
+	ancestors map[visitKey]bool
+}
+
+// enter records the pointer, map or slice val as an ancestor of the values
+// encoded until leave is called. If it already is one val can not be encoded,
+// as json.Marshal reports, and enter writes null and reports false.
+func (e *jsonState) enter(val reflect.Value) bool {
+	key := ancestorKey(val)
+	if e.ancestors[key] {
+		t := val.Type()
+		e.fail(t, &json.UnsupportedValueError{Value: val, Str: "encountered a cycle via " + t.String()})
+		e.buf.WriteString("null")
+		return false
+	}
+	e.ancestors[key] = true
+	return true
+}
+
+func (e *jsonState) leave(val reflect.Value) {
+	delete(e.ancestors, ancestorKey(val))
+}
+
+func (r *Redactor) encodeJSON(buf *bytes.Buffer, v interface{}, escapeHTML bool) error {
+	e := &jsonState{
+		walker:     r.newWalker(),
+		buf:        buf,
+		escapeHTML: escapeHTML,
+		ancestors:  map[visitKey]bool{},
+	}
+	e.checkDefaultRule()
+	e.encode(reflect.ValueOf(v), Rule{})
+	if len(e.errs) > 0 {
+		return e.errs
+	}
+	return nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// encode writes val redacted with rule.
+func (e *jsonState) encode(val reflect.Value, rule Rule) {
+	if !val.IsValid() {
+		e.buf.WriteString("null")
+		return
+	}
+	if e.resolveRule(rule).Name == "omit" {
+		// only fields and map entries can be left out
+		e.buf.WriteString("null")
+		return
+	}
+
+	t := val.Type()
+	if marshaler := t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType); marshaler || isRedactable(t) ||
+		val.CanAddr() && (reflect.PtrTo(t).Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)) {
+		e.encodeCopy(val, rule)
+		return
+	}
+
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() {
+			e.buf.WriteString("null")
+			return
+		}
+		if !e.enter(val) {
+			return
+		}
+		e.encode(val.Elem(), rule)
+		e.leave(val)
+	case reflect.Interface:
+		if val.IsNil() {
+			e.buf.WriteString("null")
+			return
+		}
+		e.encode(val.Elem(), rule)
+	case reflect.Struct:
+		e.encodeStruct(val, rule)
+	case reflect.Map:
+		if val.IsNil() {
+			e.buf.WriteString("null")
+			return
+		}
+		if !e.enter(val) {
+			return
+		}
+		e.encodeMap(val, rule)
+		e.leave(val)
+	case reflect.Slice:
+		if val.IsNil() {
+			e.buf.WriteString("null")
+			return
+		}
+		if t.Elem().Kind() == reflect.Uint8 && !isRedactable(t.Elem()) {
+			e.encodeBytes(val, rule)
+			return
+		}
+		if !e.enter(val) {
+			return
+		}
+		e.encodeElems(val, rule)
+		e.leave(val)
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && !isRedactable(t.Elem()) {
+			leaf := reflect.New(t).Elem()
+			leaf.Set(val)
+			e.fail(t, e.transformByteArray(leaf, rule))
+			e.encodeElems(leaf, Rule{Name: "snapshot"})
+			return
+		}
+		e.encodeElems(val, rule)
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		e.encodeLeaf(e.redactLeaf(val, rule))
+	default:
+		e.fail(t, &json.UnsupportedTypeError{Type: t})
+		e.buf.WriteString("null")
+	}
+}
+
+// encodeCopy writes a copy of val redacted with rule by the walker, marshaled
+// with package json. It is used for values that marshal or redact themselves.
+// Strings and bytes that marshal themselves, such as net.IP, would not marshal
+// once their contents are redacted, so they are marshaled first and their
+// text is redacted instead.
+func (e *jsonState) encodeCopy(val reflect.Value, rule Rule) {
+	if !isRedactable(val.Type()) && isTextKind(val.Type()) {
+		e.encodeText(val, rule)
+		return
+	}
+
+	c := &cloner{seen: map[cloneKey]reflect.Value{}}
+	ptr := reflect.New(val.Type())
+	ptr.Elem().Set(c.clone(val))
+	e.snapshotHelper(ptr, rule)
+
+	// pointer methods are only used for addressable values, as json does
+	cp := ptr.Elem().Interface()
+	if val.CanAddr() {
+		cp = ptr.Interface()
+	}
+
+	out, err := e.marshal(cp)
+	if err != nil {
+		e.fail(val.Type(), err)
+		e.buf.WriteString("null")
+		return
+	}
+	e.buf.Write(out)
+}
+
+// encodeText writes the JSON text val marshals to redacted with rule. A JSON
+// string is redacted as a string, other JSON values as their text, which then
+// becomes a string unless the rule keeps it.
+func (e *jsonState) encodeText(val reflect.Value, rule Rule) {
+	v := val.Interface()
+	if val.CanAddr() {
+		v = val.Addr().Interface()
+	}
+	out, err := e.marshal(v)
+	if err != nil {
+		e.fail(val.Type(), err)
+		e.buf.WriteString("null")
+		return
+	}
+
+	text := string(out)
+	if out[0] == '"' {
+		if err := json.Unmarshal(out, &text); err != nil {
+			e.fail(val.Type(), err)
+			e.buf.WriteString("null")
+			return
+		}
+	} else if isKept(e.resolveRule(rule)) {
+		e.buf.Write(out)
+		return
+	}
+
+	output, err := e.transformString(text, rule)
+	e.fail(val.Type(), err)
+	e.writeString(output)
+}
+
+// marshal returns v marshaled with package json.
+func (e *jsonState) marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(e.escapeHTML)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// isTextKind reports whether t is a string or holds bytes, which the walker
+// redacts like strings.
+func isTextKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String:
+		return true
+	case reflect.Slice, reflect.Array:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+// redactLeaf returns a copy of the string, bool or number val redacted with
+// rule.
+func (e *jsonState) redactLeaf(val reflect.Value, rule Rule) reflect.Value {
+	leaf := reflect.New(val.Type()).Elem()
+	leaf.Set(val)
+	if leaf.Kind() == reflect.String {
+		output, err := e.transformString(leaf.String(), rule)
+		e.fail(leaf.Type(), err)
+		leaf.SetString(output)
+		return leaf
+	}
+	e.fail(leaf.Type(), e.transformScalar(leaf, rule))
+	return leaf
+}
+
+// encodeLeaf writes a string, bool or number that is redacted already.
+func (e *jsonState) encodeLeaf(leaf reflect.Value) {
+	switch leaf.Kind() {
+	case reflect.String:
+		e.writeString(leaf.String())
+	case reflect.Bool:
+		e.buf.WriteString(strconv.FormatBool(leaf.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.buf.WriteString(strconv.FormatInt(leaf.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.buf.WriteString(strconv.FormatUint(leaf.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		e.writeFloat(leaf)
+	}
+}
+
+// writeFloat writes a float like package json does.
+func (e *jsonState) writeFloat(leaf reflect.Value) {
+	f, bits := leaf.Float(), leaf.Type().Bits()
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		e.fail(leaf.Type(), &json.UnsupportedValueError{Value: leaf, Str: strconv.FormatFloat(f, 'g', -1, bits)})
+		e.buf.WriteString("null")
+		return
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b := strconv.AppendFloat(nil, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	e.buf.Write(b)
+}
+
+// encodeBytes writes a byte slice redacted with rule, base64 encoded as
+// package json does. A json.RawMessage is written as it is if it is kept, and
+// as a JSON string otherwise, as Copy does.
+func (e *jsonState) encodeBytes(val reflect.Value, rule Rule) {
+	input := val.Bytes()
+	output, err := e.transformString(string(input), rule)
+	e.fail(val.Type(), err)
+
+	if val.Type() == rawMessageType {
+		if output != string(input) {
+			e.writeString(output)
+			return
+		}
+		if len(input) == 0 {
+			e.buf.WriteString("null")
+			return
+		}
+		if err := json.Compact(e.buf, input); err != nil {
+			e.fail(val.Type(), err)
+			e.buf.WriteString("null")
+		}
+		return
+	}
+
+	e.buf.WriteByte('"')
+	e.buf.WriteString(base64.StdEncoding.EncodeToString([]byte(output)))
+	e.buf.WriteByte('"')
+}
+
+// encodeElems writes the elements of a slice or an array.
+func (e *jsonState) encodeElems(val reflect.Value, rule Rule) {
+	e.buf.WriteByte('[')
+	for i := 0; i < val.Len(); i++ {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		e.push(pathElem{index: i})
+		e.encode(val.Index(i), rule)
+		e.pop()
+	}
+	e.buf.WriteByte(']')
+}
+
+// encodeStruct writes the fields of a struct that json would write, redacted
+// with the rules inherited from parent.
+func (e *jsonState) encodeStruct(val reflect.Value, parent Rule) {
+	e.buf.WriteByte('{')
+	first := true
+	for _, field := range e.jsonFieldsOf(val.Type()) {
+		fieldVal, rule, ok := e.jsonField(val, field, parent)
+		if !ok {
+			continue
+		}
+
+		e.pushField(field)
+		var leaf reflect.Value
+		if field.omitEmpty {
+			switch fieldVal.Kind() {
+			case reflect.String, reflect.Bool,
+				reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+				reflect.Float32, reflect.Float64:
+				// omitempty looks at the redacted value
+				leaf = e.redactLeaf(fieldVal, rule)
+				if leaf.IsZero() {
+					e.popField(field)
+					continue
+				}
+			default:
+				if isEmptyValue(fieldVal) {
+					e.popField(field)
+					continue
+				}
+			}
+		}
+
+		if !first {
+			e.buf.WriteByte(',')
+		}
+		first = false
+		e.writeString(field.name)
+		e.buf.WriteByte(':')
+		buf := e.buf
+		if field.quoted {
+			e.buf = &bytes.Buffer{}
+		}
+		if leaf.IsValid() {
+			e.encodeLeaf(leaf)
+		} else {
+			e.encode(fieldVal, rule)
+		}
+		if field.quoted {
+			// the string option puts the encoded value in a string, json
+			// leaves null as it is
+			quoted := e.buf.String()
+			e.buf = buf
+			if quoted == "null" {
+				e.buf.WriteString(quoted)
+			} else {
+				e.writeString(quoted)
+			}
+		}
+		e.popField(field)
+	}
+	e.buf.WriteByte('}')
+}
+
+// jsonField returns the value of field in the struct val and its rule,
+// inherited from parent through the embedded structs leading to it. It
+// reports false if the field is not written: it is behind a nil embedded
+// pointer or redacted with "omit".
+func (e *jsonState) jsonField(val reflect.Value, field jsonField, parent Rule) (reflect.Value, Rule, bool) {
+	depth := len(e.path)
+	defer func() { e.path = e.path[:depth] }()
+
+	rule := parent
+	for i, index := range field.index {
+		if val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return reflect.Value{}, Rule{}, false
+			}
+			val = val.Elem()
+		}
+		plan := e.fieldsOf(val.Type())[index]
+		val = val.Field(index)
+
+		e.push(pathElem{field: field.path[i]})
+		e.fail(val.Type(), plan.err)
+		if e.strict {
+			e.fail(val.Type(), e.checkRule(plan.rule))
+		}
+
+		// keys= and values= only apply to the map they are set on
+		rule.Keys, rule.Values = nil, nil
+		rule = inheritRule(plan.rule, rule)
+	}
+	if e.resolveRule(rule).Name == "omit" {
+		return reflect.Value{}, Rule{}, false
+	}
+	return val, rule, true
+}
+
+func (e *jsonState) pushField(field jsonField) {
+	for _, name := range field.path {
+		e.push(pathElem{field: name})
+	}
+}
+
+func (e *jsonState) popField(field jsonField) {
+	for range field.path {
+		e.pop()
+	}
+}
+
+// encodeMap writes a map as a JSON object with sorted keys, as json does.
+// Keys are redacted with the keys= rule; redacted string keys that collide
+// get a "#2", "#3", ... suffix as they do in Snapshot, other colliding keys are
+// dropped, each reporting ErrKeyCollision.
+func (e *jsonState) encodeMap(val reflect.Value, rule Rule) {
+	valueRule := rule
+	valueRule.Keys, valueRule.Values = nil, nil
+	if rule.Values != nil {
+		valueRule = *rule.Values
+	}
+
+	e.push(pathElem{index: mapEntry})
+	defer e.pop()
+
+	if e.resolveRule(valueRule).Name == "omit" {
+		e.buf.WriteString("{}")
+		return
+	}
+
+	// MapRange also works for NaN keys, which can not be looked up
+	var items []keyValue
+	iter := val.MapRange()
+	for iter.Next() {
+		items = append(items, keyValue{key: iter.Key(), value: iter.Value()})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return fmt.Sprint(items[i].key.Interface()) < fmt.Sprint(items[j].key.Interface())
+	})
+
+	type entry struct {
+		name  string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, len(items))
+	names := make(map[string]bool, len(items))
+	for _, item := range items {
+		key, redacted := item.key, item.key
+		if rule.Keys != nil {
+			// the key is redacted in a clone, as it may point into v
+			c := &cloner{seen: map[cloneKey]reflect.Value{}, unexported: e.unexported}
+			keyPtr := reflect.New(key.Type())
+			keyPtr.Elem().Set(c.clone(key))
+			e.snapshotHelper(keyPtr, *rule.Keys)
+			redacted = keyPtr.Elem()
+		}
+
+		name, err := jsonKey(redacted)
+		if err != nil {
+			e.fail(val.Type(), err)
+			continue
+		}
+		if names[name] {
+			if redacted.Kind() != reflect.String {
+				e.fail(redacted.Type(), fmt.Errorf("%w: %s", ErrKeyCollision, name))
+				continue
+			}
+			base := name
+			for n := 2; names[name]; n++ {
+				name = fmt.Sprintf("%s#%d", base, n)
+			}
+		}
+		names[name] = true
+		entries = append(entries, entry{name: name, value: item.value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	e.buf.WriteByte('{')
+	for i, entry := range entries {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		e.writeString(entry.name)
+		e.buf.WriteByte(':')
+		e.encode(entry.value, valueRule)
+	}
+	e.buf.WriteByte('}')
+}
+
+// jsonKey returns the JSON object key for a map key, as json does.
+func jsonKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if tm, ok := key.Interface().(encoding.TextMarshaler); ok {
+		if key.Kind() == reflect.Ptr && key.IsNil() {
+			return "", nil
+		}
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", &json.UnsupportedTypeError{Type: key.Type()}
+}
+
+// isEmptyValue reports whether json considers val empty for omitempty.
+func isEmptyValue(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return val.Len() == 0
+	case reflect.Interface, reflect.Ptr:
+		return val.IsNil()
+	}
+	return false
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeString writes s as a JSON string, escaped like json does.
+func (e *jsonState) writeString(s string) {
+	e.buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && (!e.escapeHTML || b != '<' && b != '>' && b != '&') {
+				i++
+				continue
+			}
+			e.buf.WriteString(s[start:i])
+			switch b {
+			case '\\', '"':
+				e.buf.WriteByte('\\')
+				e.buf.WriteByte(b)
+			case '\n':
+				e.buf.WriteString(`\n`)
+			case '\r':
+				e.buf.WriteString(`\r`)
+			case '\t':
+				e.buf.WriteString(`\t`)
+			default:
+				e.buf.WriteString(`\u00`)
+				e.buf.WriteByte(hexDigits[b>>4])
+				e.buf.WriteByte(hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			e.buf.WriteString(s[start:i])
+			e.buf.WriteString("\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are escaped for JSONP, as json does
+		if c == '\u2028' || c == '\u2029' {
+			e.buf.WriteString(s[start:i])
+			e.buf.WriteString(`\u202`)
+			e.buf.WriteByte(hexDigits[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	e.buf.WriteString(s[start:])
+	e.buf.WriteByte('"')
+}
+
+// jsonField is a field json writes for a struct type, possibly promoted from
+// embedded structs.
+type jsonField struct {
+	name      string
+	omitEmpty bool
+	// quoted is set by the string option on strings, numbers and bools
+	quoted bool
+
+	// index leads to the field through embedded structs, path holds the Go
+	// names of the fields along the way
+	index []int
+	path  []string
+	// tagged is set if the name comes from a json tag
+	tagged bool
+}
+
+// isQuotable reports whether json applies the string option to a field of
+// type t: a string, number or bool, or a pointer to one, that does not marshal
+// itself.
+func isQuotable(t reflect.Type) bool {
+	if t.Name() == "" && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PtrTo(t).Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// jsonFieldsOf returns the fields json writes for the struct type t, in the
+// order it writes them. They are worked out once per type and cached.
+func (r *Redactor) jsonFieldsOf(t reflect.Type) []jsonField {
+	if cached, ok := r.jsonFields.Load(t); ok {
+		return cached.([]jsonField)
+	}
+
+	cached, _ := r.jsonFields.LoadOrStore(t, typeJSONFields(t))
+	return cached.([]jsonField)
+}
+
+// typeJSONFields lists the fields of t like json does: fields of embedded
+// structs without a json name are promoted, and of several fields with the
+// same name the least nested one wins, or the only tagged one among equally
+// nested fields. If there is none, none is written.
+func typeJSONFields(t reflect.Type) []jsonField {
+	type level struct {
+		typ   reflect.Type
+		index []int
+		path  []string
+	}
+
+	var fields []jsonField
+	depths := map[string]int{}
+	visited := map[reflect.Type]bool{}
+	current := []level{{typ: t}}
+	for depth := 0; len(current) > 0; depth++ {
+		var next []level
+		for _, lv := range current {
+			if visited[lv.typ] {
+				continue
+			}
+			visited[lv.typ] = true
+
+			for i := 0; i < lv.typ.NumField(); i++ {
+				sf := lv.typ.Field(i)
+				ft := sf.Type
+				if sf.Anonymous && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if !sf.IsExported() && !(sf.Anonymous && ft.Kind() == reflect.Struct) {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(append([]int(nil), lv.index...), i)
+				path := append(append([]string(nil), lv.path...), sf.Name)
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, level{typ: ft, index: index, path: path})
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+
+				field := jsonField{name: name, index: index, path: path, tagged: name != ""}
+				if !isValidJSONName(name) {
+					field.name, field.tagged = sf.Name, false
+				}
+				for _, opt := range strings.Split(opts, ",") {
+					switch opt {
+					case "omitempty":
+						field.omitEmpty = true
+					case "string":
+						field.quoted = isQuotable(ft)
+					}
+				}
+				if d, ok := depths[field.name]; ok && d < depth {
+					continue
+				}
+				depths[field.name] = depth
+				fields = append(fields, field)
+			}
+		}
+		current = next
+	}
+
+	// drop the fields that lose to a field with the same name
+	byName := map[string][]int{}
+	for i, f := range fields {
+		byName[f.name] = append(byName[f.name], i)
+	}
+	var out []jsonField
+	for i, f := range fields {
+		candidates := byName[f.name]
+		if len(candidates) == 1 {
+			out = append(out, f)
+			continue
+		}
+		minDepth := len(f.index)
+		for _, c := range candidates {
+			if d := len(fields[c].index); d < minDepth {
+				minDepth = d
+			}
+		}
+		var winners []int
+		for _, c := range candidates {
+			if len(fields[c].index) == minDepth {
+				winners = append(winners, c)
+			}
+		}
+		if len(winners) > 1 {
+			var tagged []int
+			for _, c := range winners {
+				if fields[c].tagged {
+					tagged = append(tagged, c)
+				}
+			}
+			winners = tagged
+		}
+		if len(winners) == 1 && winners[0] == i {
+			out = append(out, f)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].index, out[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return out
+}
+
+// isValidJSONName reports whether name can be used as a json tag name.
+func isValidJSONName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,278 @@
+package redact_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestJSONAddress struct {
+	City   string `json:"city" redact:"snapshot"`
+	Street string `json:"street,omitempty"`
+}
+
+type TestJSONMeta struct {
+	Source  string `json:"source" redact:"snapshot"`
+	Comment string `json:"comment"`
+}
+
+type TestJSONUser struct {
+	TestJSONMeta
+	ID       string            `json:"id" redact:"snapshot"`
+	Email    string            `json:"email" redact:"email"`
+	Password string            `json:"password" redact:"omit"`
+	Nickname string            `json:"nickname,omitempty" redact:"omit"`
+	Age      int               `json:"age,omitempty" redact:"round(10)"`
+	Internal string            `json:"-" redact:"snapshot"`
+	Address  *TestJSONAddress  `json:"address,omitempty"`
+	Tags     []string          `json:"tags" redact:"snapshot"`
+	Labels   map[string]string `json:"labels,omitempty" redact:"values=snapshot"`
+	Secrets  map[string]string `json:"secrets" redact:"values=omit"`
+	Created  time.Time         `json:"created" redact:"snapshot"`
+	Raw      json.RawMessage   `json:"raw"`
+	Untagged string
+	hidden   string
+}
+
+func newJSONUser() *TestJSONUser {
+	return &TestJSONUser{
+		TestJSONMeta: TestJSONMeta{Source: "web", Comment: "vip"},
+		ID:           "user-1",
+		Email:        "alice@example.com",
+		Password:     "hunter2",
+		Nickname:     "ally",
+		Age:          42,
+		Internal:     "internal",
+		Address:      &TestJSONAddress{City: "Berlin", Street: "Unter den Linden 1"},
+		Tags:         []string{"admin"},
+		Labels:       map[string]string{"team": "payments"},
+		Secrets:      map[string]string{"token": "abc"},
+		Created:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Raw:          json.RawMessage(`{"pin": 1234}`),
+		Untagged:     "untagged",
+		hidden:       "hidden",
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	t.Run("Should write json names and redact per field", func(t *testing.T) {
+		user := newJSONUser()
+		out, err := redact.MarshalJSON(user)
+		assert.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"source": "web",
+			"comment": "NONSNAPSHOT",
+			"id": "user-1",
+			"email": "*****@example.com",
+			"age": 40,
+			"address": {"city": "Berlin", "street": "NONSNAPSHOT"},
+			"tags": ["admin"],
+			"labels": {"team": "payments"},
+			"secrets": {},
+			"created": "2024-01-02T03:04:05Z",
+			"raw": "NONSNAPSHOT",
+			"Untagged": "NONSNAPSHOT"
+		}`, string(out), "should redact the json output")
+		assert.NotContains(t, string(out), "password", "should omit fields with the omit rule")
+		assert.NotContains(t, string(out), "hunter2", "should not leak")
+
+		assert.Equal(t, newJSONUser(), user, "should not modify the original")
+	})
+
+	t.Run("Should match json.Marshal of a redacted copy", func(t *testing.T) {
+		user := newJSONUser()
+		user.Password, user.Nickname, user.Secrets = "", "", nil
+		user.Address.Street = ""
+
+		cp, err := redact.Copy(user)
+		assert.NoError(t, err)
+		expected, err := json.Marshal(cp)
+		assert.NoError(t, err)
+
+		r := redact.New()
+		user.Password, user.Nickname = "hunter2", "ally"
+		out, err := r.JSON(user)
+		assert.NoError(t, err)
+
+		var want, got map[string]interface{}
+		assert.NoError(t, json.Unmarshal(expected, &want))
+		assert.NoError(t, json.Unmarshal(out, &got))
+		delete(want, "password")
+		assert.Equal(t, want, got, "should write what json writes for a copy")
+	})
+
+	t.Run("Should quote values with the string option as json.Marshal does", func(t *testing.T) {
+		type TestJSONQuoted struct {
+			N       int      `json:",string" redact:"snapshot"`
+			Rounded *int     `json:"rounded,string" redact:"round(10)"`
+			Nil     *int     `json:"nil,string"`
+			OK      bool     `json:"ok,string"`
+			Name    string   `json:"name,string"`
+			Kept    string   `json:"kept,string" redact:"snapshot"`
+			List    []string `json:"list,string" redact:"snapshot"`
+		}
+		age := 42
+		v := TestJSONQuoted{N: 5, Rounded: &age, OK: true, Name: "alice", Kept: "<b>", List: []string{"a"}}
+
+		cp, err := redact.Copy(v)
+		assert.NoError(t, err)
+		expected, err := json.Marshal(cp)
+		assert.NoError(t, err)
+
+		out, err := redact.MarshalJSON(v)
+		assert.NoError(t, err)
+		assert.Equal(t, string(expected), string(out), "should write what json writes for a copy")
+		assert.Contains(t, string(out), `"N":"5"`)
+	})
+
+	t.Run("Should escape strings as json.Marshal does", func(t *testing.T) {
+		for _, s := range []string{"bad \xff utf-8", "line\u2028sep\u2029", "<a&b>\x01"} {
+			expected, err := json.Marshal(s)
+			assert.NoError(t, err)
+
+			out, err := redact.New().JSON(struct {
+				S string `redact:"snapshot"`
+			}{S: s})
+			assert.NoError(t, err)
+			assert.Equal(t, `{"S":`+string(expected)+`}`, string(out), "should escape %q", s)
+		}
+	})
+
+	t.Run("Should apply omitempty to the redacted value", func(t *testing.T) {
+		type TestJSONEmpty struct {
+			Count int    `json:"count,omitempty" redact:"round(10)"`
+			Name  string `json:"name,omitempty"`
+		}
+
+		out, err := redact.MarshalJSON(TestJSONEmpty{Count: 4})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"name":"NONSNAPSHOT"}`, string(out), "should leave out values redacted to zero and keep empty strings redacted")
+
+		out, err = redact.New(redact.WithPlaceholder("")).JSON(TestJSONEmpty{Name: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, `{}`, string(out), "should leave out strings redacted to empty")
+	})
+
+	t.Run("Should redact non struct values", func(t *testing.T) {
+		out, err := redact.MarshalJSON(map[int][]string{2: {"b"}, 1: {"a"}})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"1":["NONSNAPSHOT"],"2":["NONSNAPSHOT"]}`, string(out), "should sort map keys")
+
+		out, err = redact.MarshalJSON(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, `null`, string(out), "should write null")
+
+		out, err = redact.MarshalJSON([]float64{1.5, 1e21})
+		assert.NoError(t, err)
+		assert.Equal(t, `[0,0]`, string(out), "should redact numbers")
+	})
+
+	t.Run("Should redact the text of values that marshal themselves", func(t *testing.T) {
+		type TestJSONHost struct {
+			IP     net.IP `json:"ip"`
+			Mask   net.IP `json:"mask" redact:"mask(keep=2)"`
+			Server net.IP `json:"server" redact:"snapshot"`
+		}
+
+		out, err := redact.MarshalJSON(TestJSONHost{
+			IP:     net.IPv4(10, 0, 0, 1),
+			Mask:   net.IPv4(10, 0, 0, 2),
+			Server: net.IPv4(10, 0, 0, 3),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, `{"ip":"NONSNAPSHOT","mask":"******.2","server":"10.0.0.3"}`, string(out),
+			"should marshal the original and redact its text")
+	})
+
+	t.Run("Should report errors without output", func(t *testing.T) {
+		type TestJSONBroken struct {
+			Name string `json:"name" redact:"nope("`
+			Ch   chan int
+		}
+
+		out, err := redact.MarshalJSON(TestJSONBroken{Name: "alice"})
+		assert.Nil(t, out, "should not return partial output")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Name", "should report the field")
+		assert.Contains(t, err.Error(), "chan int", "should report unsupported types")
+	})
+
+	t.Run("Should redact map keys like Snapshot", func(t *testing.T) {
+		out, err := redact.MarshalJSON(map[float64]string{math.NaN(): "a", 1: "b"})
+		assert.Nil(t, out, "should not return partial output")
+		assert.Error(t, err, "should report float keys as json.Marshal does")
+
+		out, err = redact.MarshalJSON(struct {
+			Counts map[int]string `json:"counts" redact:"keys=round(10),values=snapshot"`
+		}{map[int]string{11: "a", 12: "b", 13: "c"}})
+		assert.Nil(t, out, "should not return partial output")
+		assert.True(t, errors.Is(err, redact.ErrKeyCollision), "should report dropped keys")
+		assert.Equal(t, 2, strings.Count(err.Error(), redact.ErrKeyCollision.Error()), "should report each dropped key")
+	})
+
+	t.Run("Should report cycles", func(t *testing.T) {
+		type TestJSONNode struct {
+			Next *TestJSONNode
+		}
+		node := &TestJSONNode{}
+		node.Next = node
+
+		_, err := redact.MarshalJSON(node)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "cycle", "should not loop")
+
+		m := map[string]interface{}{"secret": nonSnapshotVal}
+		m["self"] = m
+		_, err = redact.MarshalJSON(m)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "cycle", "should not loop through maps")
+
+		list := []interface{}{nonSnapshotVal, nil}
+		list[1] = list
+		_, err = redact.MarshalJSON(list)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "cycle", "should not loop through slices")
+
+		shared := []string{nonSnapshotVal}
+		out, err := redact.MarshalJSON([][]string{shared, shared, shared[:0]})
+		assert.NoError(t, err, "should allow shared values that are not cycles")
+		assert.Equal(t, `[["NONSNAPSHOT"],["NONSNAPSHOT"],[]]`, string(out))
+	})
+}
+
+func TestJSONEncoder(t *testing.T) {
+	t.Run("Should stream indented values", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := redact.NewJSONEncoder(&buf)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+
+		assert.NoError(t, enc.Encode(map[string]string{"a": "<b>"}))
+		assert.NoError(t, enc.Encode(&TestJSONAddress{City: "<Berlin>"}))
+		assert.Equal(t, "{\n  \"a\": \"NONSNAPSHOT\"\n}\n{\n  \"city\": \"<Berlin>\",\n  \"street\": \"NONSNAPSHOT\"\n}\n", buf.String())
+	})
+
+	t.Run("Should escape html by default", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, redact.NewJSONEncoder(&buf).Encode(TestJSONAddress{City: "<Berlin>"}))
+		assert.Equal(t, `{"city":"\u003cBerlin\u003e","street":"NONSNAPSHOT"}`+"\n", buf.String())
+	})
+}
+
+func TestOmitRule(t *testing.T) {
+	t.Run("Should zero omitted values in place", func(t *testing.T) {
+		user := newJSONUser()
+		assert.NoError(t, redact.Snapshot(user))
+		assert.Equal(t, "", user.Password, "should empty strings")
+		assert.Equal(t, map[string]string{"token": ""}, user.Secrets, "should empty map values")
+	})
+}
@@ -0,0 +1,291 @@
+// Package jsonpath redacts JSON documents that are not decoded into tagged
+// structs, such as webhooks and third party API responses. Rules are chosen by
+// path instead of by struct tag:
+//
+//	$.user.email: email
+//	$.cards[*].pan: last4
+//	$..password: redact
+//
+// The part after the colon is a redact tag, so it names any rule registered on
+// the redact.Redactor in use, with arguments and flags. "redact" stands for the
+// default rule, which replaces values with the placeholder, and "omit" removes
+// the member or element from the document.
+//
+// A rule on an object or array applies to everything beneath it, unless a
+// deeper path has a rule of its own. When several paths match the same value
+// the one listed last wins. Values no path leads to are left as they are, or
+// redacted with the default rule in allowlist mode, see Allowlist.
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/samkreter/redact"
+	"github.com/samkreter/redact/internal/pathrule"
+)
+
+var (
+	// ErrInvalidPath is returned for a path that does not follow the path
+	// grammar.
+	ErrInvalidPath = pathrule.ErrInvalidPath
+	// ErrInvalidRule is returned for a rule that is not a path followed by a
+	// colon and a redact tag.
+	ErrInvalidRule = pathrule.ErrInvalidRule
+)
+
+// Redactor rewrites JSON documents with rules chosen by path. It is safe for
+// concurrent use.
+type Redactor struct {
+	r         *redact.Redactor
+	rules     pathrule.Rules
+	allowlist bool
+}
+
+// Option configures a Redactor created with New.
+type Option func(*Redactor)
+
+// WithRedactor sets the redact.Redactor whose registered redactors,
+// placeholder and default rule are used, redact.Default() by default.
+func WithRedactor(r *redact.Redactor) Option {
+	return func(j *Redactor) {
+		j.r = r
+	}
+}
+
+// Allowlist redacts every value with the default rule unless a path leads to
+// it, as Snapshot does for untagged fields. Values are then kept with the
+// "snapshot" rule:
+//
+//	$.id: snapshot
+//	$.items[*].sku: snapshot
+func Allowlist() Option {
+	return func(j *Redactor) {
+		j.allowlist = true
+	}
+}
+
+// New returns a Redactor for rules, each a path and a redact tag separated by
+// a colon, e.g. "$.cards[*].pan: last4". Rules naming a redactor that is not
+// registered are rejected.
+func New(rules []string, opts ...Option) (*Redactor, error) {
+	j := &Redactor{r: redact.Default()}
+	for _, opt := range opts {
+		opt(j)
+	}
+
+	for _, text := range rules {
+		rule, err := pathrule.Parse(j.r, text, false)
+		if err != nil {
+			return nil, err
+		}
+		j.rules = append(j.rules, rule)
+	}
+	return j, nil
+}
+
+// MustNew is like New but panics if a rule does not parse. It simplifies
+// initializing package level variables.
+func MustNew(rules []string, opts ...Option) *Redactor {
+	j, err := New(rules, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return j
+}
+
+// Redact returns doc with the values the rules lead to redacted. Member order
+// is kept, insignificant whitespace is not. If a value fails to redact Redact
+// returns nil and the errors, as redact.Copy does.
+func (j *Redactor) Redact(doc []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	root, err := decode(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("jsonpath: invalid character after top-level value")
+	}
+
+	var errs redact.Errors
+	if !j.redact(root, nil, pathrule.Base(j.allowlist), &errs) {
+		root = &node{}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var buf bytes.Buffer
+	root.write(&buf)
+	return buf.Bytes(), nil
+}
+
+// redact redacts n at the location steps with the rules leading to it, or
+// with inherited. It reports false if n is to be removed.
+func (j *Redactor) redact(n *node, steps []pathrule.Step, inherited redact.Rule, errs *redact.Errors) bool {
+	rule := j.rules.Resolve(steps, inherited)
+	if rule.Name == "omit" {
+		return false
+	}
+
+	switch n.kind {
+	case objectNode:
+		var names []string
+		var children []*node
+		for i, child := range n.children {
+			if j.redact(child, append(steps, pathrule.Key(n.names[i])), rule, errs) {
+				names = append(names, n.names[i])
+				children = append(children, child)
+			}
+		}
+		n.names, n.children = names, children
+	case arrayNode:
+		var children []*node
+		for i, child := range n.children {
+			if j.redact(child, append(steps, pathrule.Index(i)), rule, errs) {
+				children = append(children, child)
+			}
+		}
+		n.children = children
+	default:
+		if rule.Name != "snapshot" {
+			if err := j.redactValue(n, rule); err != nil {
+				*errs = append(*errs, fmt.Errorf("%s: %w", pathrule.Format(steps), err))
+			}
+		}
+	}
+	return true
+}
+
+// redactValue applies rule to a string, number or bool. Numbers that are
+// integers are redacted as int64, others as float64, and are left as they were
+// written if the rule does not change them.
+func (j *Redactor) redactValue(n *node, rule redact.Rule) error {
+	switch v := n.value.(type) {
+	case string:
+		err := j.r.Apply(&v, rule)
+		n.value = v
+		return err
+	case bool:
+		err := j.r.Apply(&v, rule)
+		n.value = v
+		return err
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			redacted := i
+			err := j.r.Apply(&redacted, rule)
+			if redacted != i {
+				n.value = json.Number(strconv.FormatInt(redacted, 10))
+			}
+			return err
+		}
+		f, _ := v.Float64()
+		redacted := f
+		err := j.r.Apply(&redacted, rule)
+		if redacted != f {
+			n.value = json.Number(strconv.FormatFloat(redacted, 'g', -1, 64))
+		}
+		return err
+	}
+	return nil
+}
+
+type nodeKind int
+
+const (
+	valueNode nodeKind = iota
+	objectNode
+	arrayNode
+)
+
+// node is a decoded JSON value that keeps the order of object members.
+type node struct {
+	kind nodeKind
+	// names holds the member names of an object, children its values or the
+	// elements of an array
+	names    []string
+	children []*node
+	// value holds a string, json.Number, bool or nil
+	value interface{}
+}
+
+func decode(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		n := &node{kind: objectNode}
+		for dec.More() {
+			name, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			child, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.names = append(n.names, name.(string))
+			n.children = append(n.children, child)
+		}
+		_, err := dec.Token()
+		return n, err
+	case json.Delim('['):
+		n := &node{kind: arrayNode}
+		for dec.More() {
+			child, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		_, err := dec.Token()
+		return n, err
+	default:
+		return &node{value: tok}, nil
+	}
+}
+
+// write writes n as compact JSON.
+func (n *node) write(buf *bytes.Buffer) {
+	switch n.kind {
+	case objectNode:
+		buf.WriteByte('{')
+		for i, child := range n.children {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeValue(buf, n.names[i])
+			buf.WriteByte(':')
+			child.write(buf)
+		}
+		buf.WriteByte('}')
+	case arrayNode:
+		buf.WriteByte('[')
+		for i, child := range n.children {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			child.write(buf)
+		}
+		buf.WriteByte(']')
+	default:
+		writeValue(buf, n.value)
+	}
+}
+
+// writeValue writes a string, json.Number, bool or nil without escaping HTML,
+// so strings read as they did in the document.
+func writeValue(buf *bytes.Buffer, v interface{}) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	// the values come from the decoder and can not fail to encode
+	_ = enc.Encode(v)
+	buf.Truncate(buf.Len() - 1)
+}
@@ -0,0 +1,102 @@
+package jsonpath_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/samkreter/redact/jsonpath"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDoc = `{
+	"user": {"id": "u-1", "email": "alice@example.com", "password": "hunter2", "age": 42},
+	"cards": [
+		{"pan": "4111111111111111", "exp": "12/30"},
+		{"pan": "5500000000000004", "exp": "01/29"}
+	],
+	"auth": {"password": "s3cret", "token": "<abc>"},
+	"amount": 12.5,
+	"active": true,
+	"note": null
+}`
+
+func TestRedact(t *testing.T) {
+	t.Run("Should redact the values the paths lead to", func(t *testing.T) {
+		j, err := jsonpath.New([]string{
+			"$.user.email: email",
+			"$.cards[*].pan: last4",
+			"$..password: redact",
+			"$.auth.token: omit",
+			"$.user.age: round(10)",
+		})
+		assert.NoError(t, err)
+
+		out, err := j.Redact([]byte(testDoc))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"user":{"id":"u-1","email":"*****@example.com","password":"NONSNAPSHOT","age":40},`+
+			`"cards":[{"pan":"************1111","exp":"12/30"},{"pan":"************0004","exp":"01/29"}],`+
+			`"auth":{"password":"NONSNAPSHOT"},"amount":12.5,"active":true,"note":null}`, string(out),
+			"should keep member order and everything not matched")
+	})
+
+	t.Run("Should redact everything not kept in allowlist mode", func(t *testing.T) {
+		j, err := jsonpath.New([]string{
+			"$.user.id: snapshot",
+			"$.cards[1]: snapshot",
+			"$.cards[1].pan: last4",
+			"$.auth: omit",
+		}, jsonpath.Allowlist())
+		assert.NoError(t, err)
+
+		out, err := j.Redact([]byte(testDoc))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"user":{"id":"u-1","email":"NONSNAPSHOT","password":"NONSNAPSHOT","age":0},`+
+			`"cards":[{"pan":"NONSNAPSHOT","exp":"NONSNAPSHOT"},{"pan":"************0004","exp":"01/29"}],`+
+			`"amount":0,"active":false,"note":null}`, string(out),
+			"should apply rules to everything beneath a path")
+	})
+
+	t.Run("Should use the registry of the redactor", func(t *testing.T) {
+		r := redact.New(redact.WithPlaceholder("***"))
+		assert.NoError(t, r.RegisterRedactor("upper", strings.ToUpper))
+
+		j, err := jsonpath.New([]string{`$['user']["email"]: upper`, "$.user.id: redact"}, jsonpath.WithRedactor(r))
+		assert.NoError(t, err)
+
+		out, err := j.Redact([]byte(`{"user": {"id": "u-1", "email": "a@b.c"}}`))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"user":{"id":"***","email":"A@B.C"}}`, string(out))
+	})
+
+	t.Run("Should report failing rules without output", func(t *testing.T) {
+		j := jsonpath.MustNew([]string{"$.cards[0].pan: truncate(x)"})
+
+		out, err := j.Redact([]byte(testDoc))
+		assert.Nil(t, out)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "$.cards[0].pan", "should report the location")
+
+		_, err = j.Redact([]byte(`{"a": 1} {}`))
+		assert.Error(t, err, "should reject trailing data")
+	})
+}
+
+func TestNew(t *testing.T) {
+	for _, rule := range []string{
+		"user.email: mask",
+		"$.user.email mask",
+		"$.cards[x]: mask",
+		"$.user.: mask",
+		"$['user: mask",
+		"$.user:",
+		"$.user: mask(",
+	} {
+		_, err := jsonpath.New([]string{rule})
+		assert.Error(t, err, "should reject %q", rule)
+	}
+
+	_, err := jsonpath.New([]string{"$.user: nope"})
+	assert.True(t, errors.Is(err, redact.ErrUnknownRule), "should reject unknown rules")
+}
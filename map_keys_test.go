@@ -0,0 +1,156 @@
+package redact_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestOrder struct {
+	ID    string `redact:"snapshot"`
+	Total int
+}
+
+type TestMapKeysStruct struct {
+	Orders   map[string]TestOrder         `redact:"keys=email"`
+	Hashed   map[string]string            `redact:"keys=sha256,values=snapshot"`
+	Masked   map[string]string            `redact:"keys=fixed(4),values=last4"`
+	Counts   map[int]string               `redact:"keys=round(100),values=snapshot"`
+	Zeroed   map[int]string               `redact:"keys=mask,values=snapshot"`
+	Nested   map[string]map[string]string `redact:"keys=len"`
+	Untagged map[string]string
+}
+
+func TestMapKeyRedaction(t *testing.T) {
+	t.Run("Should redact maps with NaN keys", func(t *testing.T) {
+		m := map[float64]string{math.NaN(): nonSnapshotVal, math.NaN(): nonSnapshotVal, 1: nonSnapshotVal}
+
+		err := redact.Snapshot(&m)
+		assert.NoError(t, err, "should not fail to redact map")
+		assert.Len(t, m, 3, "should keep every entry")
+		for _, v := range m {
+			assert.Equal(t, redact.RedactStrConst, v, "should redact values of NaN keys")
+		}
+
+		rounded := map[float64]string{math.NaN(): nonSnapshotVal, 12: nonSnapshotVal}
+		tStruct := &struct {
+			M map[float64]string `redact:"keys=round(10)"`
+		}{rounded}
+		err = redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact keys")
+		assert.Len(t, tStruct.M, 2, "should keep every entry")
+		assert.Equal(t, redact.RedactStrConst, tStruct.M[10], "should redact keys")
+	})
+
+	t.Run("Should apply separate key and value rules", func(t *testing.T) {
+		tStruct := &TestMapKeysStruct{
+			Orders: map[string]TestOrder{
+				"gopher@example.com": {ID: "order-1", Total: 42},
+			},
+			Hashed: map[string]string{
+				"hunter2": snapshotVal,
+			},
+			Untagged: map[string]string{
+				"key": nonSnapshotVal,
+			},
+		}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, map[string]TestOrder{"******@example.com": {ID: "order-1"}}, tStruct.Orders, "should redact keys and values")
+		assert.Equal(t, map[string]string{
+			"f52fbd32b2b3b86ff88ef6c490628285f482af15ddcb29541f94bcf526a3f6c7": snapshotVal,
+		}, tStruct.Hashed, "should hash keys and keep values")
+		assert.Equal(t, map[string]string{"key": redact.RedactStrConst}, tStruct.Untagged, "should keep keys without a keys rule")
+	})
+
+	t.Run("Should resolve key collisions deterministically", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			tStruct := &TestMapKeysStruct{
+				Masked: map[string]string{
+					"bob":   "0000111122223333",
+					"alice": "4444555566667777",
+					"carol": "8888999900001111",
+				},
+				Counts: map[int]string{
+					1210: "b",
+					1190: "a",
+					1500: "c",
+				},
+				Zeroed: map[int]string{
+					2: "b",
+					1: "a",
+				},
+			}
+
+			err := redact.Snapshot(tStruct)
+			var errs redact.Errors
+			assert.True(t, errors.As(err, &errs), "should report dropped keys")
+			assert.Len(t, errs, 2, "should report each dropped key")
+			assert.True(t, errors.Is(err, redact.ErrKeyCollision), "should report key collisions")
+
+			assert.Equal(t, map[string]string{
+				"****":   "************7777",
+				"****#2": "************3333",
+				"****#3": "************1111",
+			}, tStruct.Masked, "should suffix colliding string keys in key order")
+			assert.Equal(t, map[int]string{1200: "a", 1500: "c"}, tStruct.Counts, "should keep the first colliding number key")
+			assert.Equal(t, map[int]string{0: "a"}, tStruct.Zeroed, "should keep the first colliding number key")
+		}
+	})
+
+	t.Run("Should apply the keys rule to the tagged map only", func(t *testing.T) {
+		tStruct := &TestMapKeysStruct{
+			Nested: map[string]map[string]string{
+				"outer": {"inner": nonSnapshotVal},
+			},
+		}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+		assert.Equal(t, map[string]map[string]string{"5": {"inner": redact.RedactStrConst}}, tStruct.Nested)
+	})
+
+	t.Run("Should not modify the original when copying", func(t *testing.T) {
+		tStruct := TestMapKeysStruct{
+			Hashed: map[string]string{"hunter2": snapshotVal},
+		}
+
+		redacted, err := redact.Redacted(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+		assert.NotContains(t, redacted.Hashed, "hunter2", "should redact the copy")
+		assert.Contains(t, tStruct.Hashed, "hunter2", "should not modify the original")
+	})
+
+	t.Run("Should not redact the original through pointer keys", func(t *testing.T) {
+		type TestPointerKeys struct {
+			M map[*string]string `redact:"keys=mask,values=snapshot"`
+		}
+		key := nonSnapshotVal
+		tStruct := TestPointerKeys{M: map[*string]string{&key: snapshotVal}}
+
+		redacted, err := redact.Redacted(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+		for k := range redacted.M {
+			assert.NotEqual(t, nonSnapshotVal, *k, "should redact the copied key")
+		}
+		assert.Equal(t, nonSnapshotVal, key, "should not modify the original when copying")
+
+		// json can not encode *string keys, but they are redacted first
+		_, err = redact.MarshalJSON(tStruct)
+		assert.Error(t, err, "should report the unsupported key")
+		assert.Equal(t, nonSnapshotVal, key, "should not modify the original when marshaling")
+	})
+
+	t.Run("Should report malformed key rules", func(t *testing.T) {
+		_, err := redact.ParseRule("keys=mask(keep=2,values=snapshot")
+		assert.ErrorIs(t, err, redact.ErrInvalidTag)
+
+		_, err = redact.ParseRule("keys=bad name")
+		assert.ErrorIs(t, err, redact.ErrInvalidTag)
+	})
+}
@@ -0,0 +1,180 @@
+package redact
+
+import (
+	"reflect"
+)
+
+// fieldPlan is what the walker needs to know about a struct field.
+type fieldPlan struct {
+	name     string
+	exported bool
+	// promoted is set for an embedded unexported struct, or pointer to one,
+	// whose exported fields are promoted and walked like the exported ones
+	promoted bool
+	rule     Rule
+	err      error
+}
+
+// fieldsOf returns the fields of the struct type t with their parsed tags,
+// indexed like the fields. Tags are parsed once per type and cached.
+func (r *Redactor) fieldsOf(t reflect.Type) []fieldPlan {
+	if cached, ok := r.fields.Load(t); ok {
+		return cached.([]fieldPlan)
+	}
+
+	cached, _ := r.fields.LoadOrStore(t, r.parseFields(t))
+	return cached.([]fieldPlan)
+}
+
+// parseFields parses the tags of the fields of the struct type t.
+func (r *Redactor) parseFields(t reflect.Type) []fieldPlan {
+	fields := make([]fieldPlan, t.NumField())
+	for i := range fields {
+		field := t.Field(i)
+		fields[i] = fieldPlan{name: field.Name, exported: field.IsExported(), promoted: isPromoted(field)}
+
+		tag := field.Tag.Get(r.tagName)
+		rule, err := ParseRule(tag)
+		if err != nil {
+			// A tag that does not parse is never a valid rule name, so the
+			// field falls back to the placeholder instead of leaking.
+			fields[i].rule, fields[i].err = Rule{Name: tag}, err
+			continue
+		}
+		fields[i].rule = rule
+	}
+	return fields
+}
+
+// isPromoted reports whether field is an embedded unexported struct, or
+// pointer to one, whose exported fields are promoted to the embedding struct.
+func isPromoted(field reflect.StructField) bool {
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return field.Anonymous && !field.IsExported() && t.Kind() == reflect.Struct
+}
+
+// typePlan records how the walker redacts values of a type, and which it can
+// skip because redacting them would not change anything. Plans are immutable,
+// worked out once per Redactor and type and cached, see planOf. clean and kept
+// are indexed by whether unexported fields are walked.
+type typePlan struct {
+	// redactable is set if the type redacts itself, see Redactable
+	redactable bool
+	// generated is set if pointers to the type have generated methods
+	generated bool
+
+	// clean is set if no rule changes the value, e.g. a struct of channels, or
+	// time.Time whose fields are all unexported
+	clean [2]bool
+	// kept is set if the "snapshot" rule does not change the value, i.e.
+	// nothing beneath it is tagged with another rule or redacts itself
+	kept [2]bool
+}
+
+// planOf returns the plan for values of type t.
+func (r *Redactor) planOf(t reflect.Type) *typePlan {
+	if cached, ok := r.plans.Load(t); ok {
+		return cached.(*typePlan)
+	}
+
+	plan := &typePlan{
+		redactable: isRedactable(t),
+		generated:  reflect.PtrTo(t).Implements(generatedType),
+	}
+	for i, unexported := range []bool{false, true} {
+		plan.clean[i] = !r.dirty(t, false, unexported, map[planKey]bool{})
+		plan.kept[i] = !r.dirty(t, true, unexported, map[planKey]bool{})
+	}
+
+	cached, _ := r.plans.LoadOrStore(t, plan)
+	return cached.(*typePlan)
+}
+
+// skip reports whether the walker can skip a value with the given plan
+// redacted with rule. Strict mode never skips, as it reports problems anywhere
+// in the value.
+func (w *walker) skip(plan *typePlan, rule Rule) bool {
+	if w.strict || w.uncompiled {
+		return false
+	}
+
+	u := 0
+	if w.unexported {
+		u = 1
+	}
+	return plan.clean[u] || (plan.kept[u] && isKept(w.resolveRule(rule)))
+}
+
+type planKey struct {
+	typ  reflect.Type
+	kept bool
+}
+
+// dirty reports whether redacting a value of type t can change it. If kept is
+// set the value is redacted with the "snapshot" rule, otherwise with any rule.
+// seen holds the types already looked at, which are not dirty or the walk
+// would have stopped, so recursive types terminate.
+func (r *Redactor) dirty(t reflect.Type, kept, unexported bool, seen map[planKey]bool) bool {
+	key := planKey{typ: t, kept: kept}
+	if seen[key] {
+		return false
+	}
+	seen[key] = true
+
+	if isRedactable(t) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return !kept
+	case reflect.Interface:
+		// the dynamic value may be anything
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return r.dirty(t.Elem(), kept, unexported, seen)
+	case reflect.Map:
+		// keys only change with a keys= rule, which kept rules do not have
+		return (!kept && r.dirty(t.Key(), kept, unexported, seen)) || r.dirty(t.Elem(), kept, unexported, seen)
+	case reflect.Struct:
+		for i, field := range r.fieldsOf(t) {
+			if !field.exported && !field.promoted && !unexported {
+				continue
+			}
+			if field.err != nil {
+				return true
+			}
+
+			fieldKept := kept
+			rule := inheritRule(field.rule, Rule{})
+			switch {
+			case field.rule.Name == "" || field.rule.Name == "inherit":
+				if rule.Keys != nil || rule.Values != nil {
+					fieldKept = false
+				}
+			case isKept(r.resolveRule(rule)):
+				fieldKept = true
+			default:
+				fieldKept = false
+			}
+			if kept && !fieldKept {
+				return true
+			}
+			if r.dirty(t.Field(i).Type, fieldKept, unexported, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isKept reports whether rule keeps values as they are.
+func isKept(rule Rule) bool {
+	return rule.Name == "snapshot" && rule.Keys == nil && rule.Values == nil
+}
@@ -0,0 +1,172 @@
+package redact
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type benchFlat struct {
+	ID      string `redact:"snapshot"`
+	Name    string
+	Email   string `redact:"email"`
+	Card    string `redact:"last4"`
+	Age     int
+	Balance float64 `redact:"round(100)"`
+	Active  bool    `redact:"snapshot"`
+	Note    string  `redact:"truncate(4)"`
+}
+
+type benchAudit struct {
+	Created time.Time
+	Updated time.Time
+	Done    chan struct{}
+	By      struct {
+		ID   string
+		Role string
+	}
+}
+
+type benchNested struct {
+	Flat  benchFlat  `redact:"snapshot"`
+	Audit benchAudit `redact:"snapshot"`
+	Owner struct {
+		Name    string
+		Contact benchFlat
+	}
+	Times []time.Time
+}
+
+type benchSlices struct {
+	IDs   []string `redact:"snapshot"`
+	Tags  []string
+	Items []benchFlat
+	Audit []benchAudit `redact:"snapshot"`
+}
+
+type benchMaps struct {
+	Labels map[string]string `redact:"snapshot"`
+	Env    map[string]string
+	Items  map[string]*benchFlat
+	Audit  map[string]benchAudit `redact:"snapshot"`
+}
+
+func newBenchFlat(i int) benchFlat {
+	return benchFlat{
+		ID:      fmt.Sprintf("id-%d", i),
+		Name:    "Alice Example",
+		Email:   "alice@example.com",
+		Card:    "4111111111111111",
+		Age:     42,
+		Balance: 1234.5,
+		Active:  true,
+		Note:    "a note about alice",
+	}
+}
+
+var benchTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newBenchAudit() benchAudit {
+	return benchAudit{Created: benchTime, Updated: benchTime}
+}
+
+func newBenchNested() *benchNested {
+	v := &benchNested{Flat: newBenchFlat(0), Audit: newBenchAudit()}
+	v.Owner.Name = "Bob"
+	v.Owner.Contact = newBenchFlat(1)
+	for i := 0; i < 10; i++ {
+		v.Times = append(v.Times, benchTime)
+	}
+	return v
+}
+
+func newBenchSlices() *benchSlices {
+	v := &benchSlices{}
+	for i := 0; i < 100; i++ {
+		v.IDs = append(v.IDs, fmt.Sprintf("id-%d", i))
+		v.Tags = append(v.Tags, fmt.Sprintf("tag-%d", i))
+		v.Items = append(v.Items, newBenchFlat(i))
+		v.Audit = append(v.Audit, newBenchAudit())
+	}
+	return v
+}
+
+func newBenchMaps() *benchMaps {
+	v := &benchMaps{
+		Labels: map[string]string{},
+		Env:    map[string]string{},
+		Items:  map[string]*benchFlat{},
+		Audit:  map[string]benchAudit{},
+	}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		item := newBenchFlat(i)
+		v.Labels[key] = "label"
+		v.Env[key] = "secret"
+		v.Items[key] = &item
+		v.Audit[key] = newBenchAudit()
+	}
+	return v
+}
+
+var benchCases = []struct {
+	name string
+	new  func() interface{}
+}{
+	{"flat", func() interface{} { v := newBenchFlat(0); return &v }},
+	{"nested", func() interface{} { return newBenchNested() }},
+	{"slices", func() interface{} { return newBenchSlices() }},
+	{"maps", func() interface{} { return newBenchMaps() }},
+}
+
+// snapshotUncompiled redacts v like Snapshot without plans.
+func snapshotUncompiled(r *Redactor, v interface{}) error {
+	w := r.newWalker()
+	w.uncompiled = true
+	return r.snapshot(reflect.ValueOf(v), w, Rule{})
+}
+
+func TestPlans(t *testing.T) {
+	for _, tc := range benchCases {
+		t.Run("Should redact "+tc.name+" values like the uncompiled walker", func(t *testing.T) {
+			compiled, uncompiled := tc.new(), tc.new()
+
+			assert.NoError(t, New().Snapshot(compiled), "should not fail to redact")
+			assert.NoError(t, snapshotUncompiled(New(), uncompiled), "should not fail to redact")
+			assert.Equal(t, uncompiled, compiled, "should redact the same way")
+		})
+	}
+
+	t.Run("Should only skip values nothing beneath changes", func(t *testing.T) {
+		r := New()
+		assert.True(t, r.planOf(reflect.TypeOf(time.Time{})).clean[0], "should skip unexported fields")
+		assert.False(t, r.planOf(reflect.TypeOf(time.Time{})).clean[1], "should walk unexported fields when asked to")
+		assert.True(t, r.planOf(reflect.TypeOf(benchAudit{})).kept[0], "should skip kept structs")
+		assert.False(t, r.planOf(reflect.TypeOf(benchAudit{})).clean[0], "should walk untagged strings")
+		assert.False(t, r.planOf(reflect.TypeOf(benchFlat{})).kept[0], "should walk structs with tagged fields")
+	})
+}
+
+func BenchmarkSnapshot(b *testing.B) {
+	for _, tc := range benchCases {
+		b.Run(tc.name+"/compiled", func(b *testing.B) {
+			r := New()
+			v := tc.new()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = r.Snapshot(v)
+			}
+		})
+		b.Run(tc.name+"/uncompiled", func(b *testing.B) {
+			r := New()
+			v := tc.new()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = snapshotUncompiled(r, v)
+			}
+		})
+	}
+}
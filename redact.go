@@ -1,8 +1,10 @@
 package redact
 
 import (
-	"errors"
+	"encoding/json"
+	"fmt"
 	"reflect"
+	"sort"
 )
 
 const (
@@ -10,104 +12,421 @@ const (
 	RedactStrConst = "NONSNAPSHOT"
 )
 
-type redactor func(string) string
-
-var redactors = map[string]redactor{}
-
-// Snapshot redacts all strings without the "snapshot" tag
+// Snapshot redacts all strings without the "snapshot" tag in place using the
+// default Redactor. iface must be a pointer; it may point at a struct, slice,
+// map, string or another pointer.
 func Snapshot(iface interface{}) error {
+	return defaultRedactor.Snapshot(iface)
+}
+
+// Snapshot redacts all strings without the "snapshot" tag in place. iface
+// must be a pointer; it may point at a struct, slice, map, string or another
+// pointer.
+func (r *Redactor) Snapshot(iface interface{}) error {
 	ifv := reflect.ValueOf(iface)
 	if ifv.Kind() != reflect.Ptr {
-		return errors.New("Not a pointer")
+		return ErrNotPointer
+	}
+
+	return r.snapshot(ifv, r.newWalker(), Rule{})
+}
+
+// Apply redacts the value ptr points to in place with rule using the default
+// Redactor, see Redactor.Apply.
+func Apply(ptr interface{}, rule Rule) error {
+	return defaultRedactor.Apply(ptr, rule)
+}
+
+// Apply redacts the value ptr points to in place as if it was a field tagged
+// with rule, e.g. a string is replaced with the result of the redactor the rule
+// names and a struct is walked with rule inherited by its untagged fields.
+// It lets code redact values it did not declare, such as decoded documents,
+// with the rules registered on r.
+func (r *Redactor) Apply(ptr interface{}, rule Rule) error {
+	ifv := reflect.ValueOf(ptr)
+	if ifv.Kind() != reflect.Ptr {
+		return ErrNotPointer
+	}
+
+	w := r.newWalker()
+	if w.strict {
+		w.fail(ifv.Type().Elem(), w.checkRule(rule))
+	}
+	return r.snapshot(ifv, w, rule)
+}
+
+// snapshot redacts the value ifv points to with w and rule.
+func (r *Redactor) snapshot(ifv reflect.Value, w *walker, rule Rule) error {
+	w.checkDefaultRule()
+	w.snapshotHelper(ifv, rule)
+	if len(w.errs) > 0 {
+		return w.errs
+	}
+	return nil
+}
+
+// checkDefaultRule records the errors of the default rule, which apply to
+// every walk.
+func (w *walker) checkDefaultRule() {
+	if w.defaultRuleErr != nil {
+		w.errs = append(w.errs, w.defaultRuleErr)
+	}
+	if w.strict {
+		if err := w.checkRule(w.defaultRule); err != nil {
+			w.errs = append(w.errs, err)
+		}
 	}
+}
+
+// Redacted returns a redacted deep copy of v with the same static type using
+// the default Redactor. v is left untouched, see Copy.
+func Redacted[T any](v T) (T, error) {
+	var zero T
+
+	cp, err := defaultRedactor.Copy(v)
+	if err != nil || cp == nil {
+		return zero, err
+	}
+
+	return cp.(T), nil
+}
+
+// walker holds the state of one walk over a value.
+type walker struct {
+	*Redactor
+
+	// visited holds the references already redacted and the rules they were
+	// redacted with, so shared values are redacted once per rule and cycles
+	// terminate. The references are kept so that temporary copies can not be
+	// freed and their address reused.
+	visited map[visitKey]*visitedRef
+
+	// unexported is set when walking a copy made with WithUnexported
+	unexported bool
+
+	// uncompiled parses the tags of every struct and walks every value, as
+	// the walker did before plans, for comparison in benchmarks
+	uncompiled bool
+
+	// path leads from the root to the value being redacted, it is only
+	// formatted when an error is reported
+	path []pathElem
+	errs Errors
+}
+
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+	// len tells apart slices of different lengths starting at the same element
+	len int
+}
+
+// ancestorKey returns the key of the pointer, map or slice val, for encoders
+// that detect cycles by the values on the way to the one they encode.
+func ancestorKey(val reflect.Value) visitKey {
+	key := visitKey{ptr: val.Pointer(), typ: val.Type()}
+	if val.Kind() == reflect.Slice {
+		key.len = val.Len()
+	}
+	return key
+}
+
+type visitedRef struct {
+	ref   reflect.Value
+	rules []Rule
+}
+
+func (r *Redactor) newWalker() *walker {
+	return &walker{Redactor: r, visited: map[visitKey]*visitedRef{}}
+}
+
+// visit marks the pointer or map ref as visited with rule and reports whether
+// it was not visited with the same rule before. A value shared by fields with
+// different rules is redacted with each of them, so none of the fields shows
+// more than its rule allows.
+func (w *walker) visit(ref reflect.Value, rule Rule) bool {
+	key := visitKey{ptr: ref.Pointer(), typ: ref.Type()}
+	rule = w.resolveRule(rule)
+	rule.inherited = false
+
+	seen, ok := w.visited[key]
+	if !ok {
+		w.visited[key] = &visitedRef{ref: ref, rules: []Rule{rule}}
+		return true
+	}
+	for _, r := range seen.rules {
+		if reflect.DeepEqual(r, rule) {
+			return false
+		}
+	}
+	seen.rules = append(seen.rules, rule)
+	return true
+}
+
+// fail records err for the value of type typ at the current path.
+func (w *walker) fail(typ reflect.Type, err error) {
+	if err == nil {
+		return
+	}
+	w.errs = append(w.errs, &FieldError{Path: formatPath(w.path), Type: typ, Err: err})
+}
+
+func (w *walker) push(elem pathElem) {
+	w.path = append(w.path, elem)
+}
+
+func (w *walker) pop() {
+	w.path = w.path[:len(w.path)-1]
+}
+
+// snapshotStruct redacts all strings without the "snapshot" tag in the struct
+// ifv points to. parent is the rule of the field holding the struct, which
+// untagged fields inherit, see inheritRule.
+func (w *walker) snapshotStruct(ifv reflect.Value, parent Rule) {
+	// keys= and values= only apply to the map they are set on
+	parent.Keys, parent.Values = nil, nil
 
 	ift := reflect.Indirect(ifv).Type()
-	if ift.Kind() != reflect.Struct {
-		return nil
+	var fields []fieldPlan
+	if w.uncompiled {
+		fields = w.parseFields(ift)
+	} else {
+		fields = w.fieldsOf(ift)
 	}
-	for i := 0; i < ift.NumField(); i++ {
-		v := ift.Field(i)
-		el := reflect.Indirect(ifv.Elem().FieldByName(v.Name))
-		switch el.Kind() {
-		case reflect.Struct:
-			if el.CanAddr() && el.Addr().CanInterface() {
-				Snapshot(el.Addr().Interface())
-			}
-		case reflect.String:
-			if el.CanSet() {
-				tagVal := v.Tag.Get(tagName)
-				input := el.String()
-				el.SetString(transformString(input, tagVal))
-			}
-		default:
-			tagVal := v.Tag.Get(tagName)
-			if el.CanAddr() && el.Addr().CanInterface() {
-				snapshotHelper(el.Addr().Interface(), tagVal)
-			}
+	for i, plan := range fields {
+		field := ifv.Elem().Field(i)
+		if w.unexported && !field.CanInterface() && field.CanAddr() {
+			field = unexportedField(field)
+		}
 
+		w.push(pathElem{field: plan.name})
+		w.fail(field.Type(), plan.err)
+		if w.strict {
+			w.fail(field.Type(), w.checkRule(plan.rule))
+		}
+		rule := inheritRule(plan.rule, parent)
+		if field.CanAddr() && field.Addr().CanInterface() {
+			w.snapshotHelper(field.Addr(), rule)
+		} else if plan.promoted && field.CanAddr() {
+			w.snapshotPromoted(field, rule)
+		} else if w.strict && w.resolveRule(rule).Name != "snapshot" {
+			w.fail(field.Type(), ErrUnexportedField)
 		}
+		w.pop()
 	}
-	return nil
 }
 
-func snapshotHelper(iface interface{}, tagVal string) error {
-	ifv := reflect.ValueOf(iface)
-	if ifv.Kind() != reflect.Ptr {
-		return errors.New("Not a pointer")
+// snapshotPromoted redacts the exported fields of the embedded unexported
+// struct, or pointer to one, field with rule. The field itself can not be
+// used, but the fields promoted from it can.
+func (w *walker) snapshotPromoted(field reflect.Value, rule Rule) {
+	ptr := field.Addr()
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return
+		}
+		ptr = field
+	}
+	if w.skip(w.planOf(ptr.Type().Elem()), rule) || !w.visit(ptr, rule) {
+		return
+	}
+	w.snapshotStruct(ptr, rule)
+}
+
+// snapshotHelper redacts the value ifv points to with rule.
+func (w *walker) snapshotHelper(ifv reflect.Value, rule Rule) {
+	if ifv.IsNil() {
+		return
+	}
+	plan := w.planOf(ifv.Type().Elem())
+	if w.skip(plan, rule) || !w.visit(ifv, rule) || w.redactSelf(ifv, plan, rule) || w.redactGenerated(ifv, plan, rule) {
+		return
 	}
 
 	ifIndirectValue := reflect.Indirect(ifv)
 	switch ifIndirectValue.Kind() {
 	case reflect.Slice:
 		if ifIndirectValue.CanInterface() {
-			elType := getSliceElemType(ifIndirectValue.Type())
-
-			// allow strings and string pointers
-			str := ""
-			if (elType.ConvertibleTo(reflect.TypeOf(str)) && reflect.TypeOf(str).ConvertibleTo(elType)) ||
-				(elType.ConvertibleTo(reflect.TypeOf(&str)) && reflect.TypeOf(&str).ConvertibleTo(elType)) {
-				for i := 0; i < ifIndirectValue.Len(); i++ {
-					ifIndirectValue.Index(i).Set(transformValue(tagVal, ifIndirectValue.Index(i)))
-				}
-			} else {
-				val := reflect.ValueOf(ifIndirectValue.Interface())
-				for i := 0; i < val.Len(); i++ {
-					elVal := val.Index(i)
-					if elVal.Kind() != reflect.Ptr {
-						elVal = elVal.Addr()
-					}
-					snapshotHelper(elVal.Interface(), tagVal)
-				}
-			}
+			w.snapshotElems(ifIndirectValue, rule)
+		}
+	case reflect.Array:
+		if ifIndirectValue.CanSet() {
+			w.snapshotElems(ifIndirectValue, rule)
 		}
 	case reflect.Map:
 		if ifIndirectValue.CanInterface() {
-			val := reflect.ValueOf(ifIndirectValue.Interface())
-			for _, key := range val.MapKeys() {
-				mapValue := val.MapIndex(key)
-				mapValuePtr := reflect.New(mapValue.Type())
-				mapValuePtr.Elem().Set(mapValue)
-				if mapValuePtr.Elem().CanAddr() {
-					snapshotHelper(mapValuePtr.Elem().Addr().Interface(), tagVal)
-				}
-				val.SetMapIndex(key, reflect.Indirect(mapValuePtr))
-			}
+			w.snapshotMap(reflect.ValueOf(ifIndirectValue.Interface()), rule)
 		}
 	case reflect.Struct:
 		if ifIndirectValue.CanAddr() && ifIndirectValue.Addr().CanInterface() {
-			Snapshot(ifIndirectValue.Addr().Interface())
+			w.snapshotStruct(ifIndirectValue.Addr(), rule)
 		}
 	case reflect.String:
 		if ifIndirectValue.CanSet() {
-			input := ifIndirectValue.String()
-			ifIndirectValue.SetString(transformString(input, tagVal))
+			output, err := w.transformString(ifIndirectValue.String(), rule)
+			w.fail(ifIndirectValue.Type(), err)
+			ifIndirectValue.SetString(output)
+		}
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if ifIndirectValue.CanSet() {
+			w.fail(ifIndirectValue.Type(), w.transformScalar(ifIndirectValue, rule))
 		}
 	case reflect.Ptr:
 		if ifIndirectValue.CanInterface() {
-			snapshotHelper(ifIndirectValue.Interface(), tagVal)
+			w.snapshotHelper(ifIndirectValue, rule)
+		}
+	case reflect.Interface:
+		// the dynamic value is not addressable, so redact a copy of it and
+		// store that back
+		if ifIndirectValue.CanSet() && !ifIndirectValue.IsNil() {
+			elem := ifIndirectValue.Elem()
+			elemPtr := reflect.New(elem.Type())
+			elemPtr.Elem().Set(elem)
+			w.snapshotHelper(elemPtr, rule)
+			ifIndirectValue.Set(elemPtr.Elem())
+		}
+	default:
+		if w.strict && w.resolveRule(rule).Name != "snapshot" {
+			w.fail(ifIndirectValue.Type(), fmt.Errorf("%w %s", ErrUnsupportedKind, ifIndirectValue.Kind()))
+		}
+	}
+}
+
+// snapshotMap redacts the values of a map with the values= rule, or with rule
+// itself if there is none. Keys are only changed if rule has a keys= rule,
+// which applies to this map alone and not to maps nested in its values.
+func (w *walker) snapshotMap(val reflect.Value, rule Rule) {
+	if val.IsNil() || !w.visit(val, rule) {
+		return
+	}
+
+	valueRule := rule
+	valueRule.Keys, valueRule.Values = nil, nil
+	if rule.Values != nil {
+		valueRule = *rule.Values
+	}
+	if rule.Keys != nil {
+		w.snapshotMapKeys(val, *rule.Keys, valueRule)
+		return
+	}
+
+	w.push(pathElem{index: mapEntry})
+	defer w.pop()
+
+	// NaN keys can not be looked up, so the entries are read with an iterator
+	// and, if there are such keys, the map is refilled
+	entries := mapEntries(val)
+	refill := false
+	for _, e := range entries {
+		w.snapshotHelper(e.value.Addr(), valueRule)
+		refill = refill || !e.key.Equal(e.key)
+	}
+	if refill {
+		val.Clear()
+	}
+	for _, e := range entries {
+		val.SetMapIndex(e.key, e.value)
+	}
+}
+
+// keyValue is a map entry read with reflection.
+type keyValue struct{ key, value reflect.Value }
+
+// mapEntries returns the entries of the map val with addressable copies of
+// the values. Unlike MapIndex this works for NaN keys.
+func mapEntries(val reflect.Value) []keyValue {
+	entries := make([]keyValue, 0, val.Len())
+	iter := val.MapRange()
+	for iter.Next() {
+		value := reflect.New(val.Type().Elem()).Elem()
+		value.Set(iter.Value())
+		entries = append(entries, keyValue{key: iter.Key(), value: value})
+	}
+	return entries
+}
+
+// snapshotMapKeys redacts both the keys and the values of a map. Entries are
+// handled sorted by their original keys as printed by fmt, so when redacted
+// keys collide the result is deterministic: the first entry keeps the key, later
+// string keys get a "#2", "#3", ... suffix and later keys of other kinds are
+// dropped, each reporting ErrKeyCollision.
+func (w *walker) snapshotMapKeys(val reflect.Value, keyRule, valueRule Rule) {
+	w.push(pathElem{index: mapEntry})
+	defer w.pop()
+
+	entries := mapEntries(val)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return fmt.Sprint(entries[i].key.Interface()) < fmt.Sprint(entries[j].key.Interface())
+	})
+
+	for i, e := range entries {
+		keyPtr := reflect.New(e.key.Type())
+		keyPtr.Elem().Set(e.key)
+		w.snapshotHelper(keyPtr, keyRule)
+		w.snapshotHelper(e.value.Addr(), valueRule)
+		entries[i].key = keyPtr.Elem()
+	}
+
+	val.Clear()
+	for _, e := range entries {
+		key := e.key
+		if val.MapIndex(key).IsValid() {
+			if key.Kind() != reflect.String {
+				w.fail(key.Type(), fmt.Errorf("%w: %v", ErrKeyCollision, key))
+				continue
+			}
+			for n := 2; val.MapIndex(key).IsValid(); n++ {
+				key = reflect.ValueOf(fmt.Sprintf("%s#%d", e.key.String(), n)).Convert(key.Type())
+			}
+		}
+		val.SetMapIndex(key, e.value)
+	}
+}
+
+// snapshotElems redacts the elements of a slice or an addressable array.
+func (w *walker) snapshotElems(val reflect.Value, rule Rule) {
+	elType := getSliceElemType(val.Type())
+
+	// byte slices and arrays are redacted as a whole, like strings
+	if elType.Kind() == reflect.Uint8 && !isRedactable(elType) {
+		if val.Kind() == reflect.Array {
+			w.fail(val.Type(), w.transformByteArray(val, rule))
+			return
+		}
+		w.fail(val.Type(), w.transformBytes(val, rule))
+		return
+	}
+
+	// allow strings and string pointers
+	str := ""
+	if !isRedactable(elType) && ((elType.ConvertibleTo(reflect.TypeOf(str)) && reflect.TypeOf(str).ConvertibleTo(elType)) ||
+		(elType.ConvertibleTo(reflect.TypeOf(&str)) && reflect.TypeOf(&str).ConvertibleTo(elType))) {
+		for i := 0; i < val.Len(); i++ {
+			if !w.visit(val.Index(i).Addr(), rule) {
+				continue
+			}
+			newVal, err := w.transformValue(rule, val.Index(i))
+			if err != nil {
+				w.push(pathElem{index: i})
+				w.fail(elType, err)
+				w.pop()
+			}
+			val.Index(i).Set(newVal)
+		}
+	} else {
+		for i := 0; i < val.Len(); i++ {
+			elVal := val.Index(i)
+			if elVal.Kind() != reflect.Ptr {
+				elVal = elVal.Addr()
+			}
+			w.push(pathElem{index: i})
+			w.snapshotHelper(elVal, rule)
+			w.pop()
 		}
 	}
-	return nil
 }
 
 func getSliceElemType(t reflect.Type) reflect.Type {
@@ -121,9 +440,9 @@ func getSliceElemType(t reflect.Type) reflect.Type {
 	return elType
 }
 
-func transformValue(tags string, val reflect.Value) reflect.Value {
+func (r *Redactor) transformValue(rule Rule, val reflect.Value) (reflect.Value, error) {
 	if val.Kind() == reflect.Ptr && val.IsNil() {
-		return val
+		return val, nil
 	}
 
 	var oldStr string
@@ -133,7 +452,7 @@ func transformValue(tags string, val reflect.Value) reflect.Value {
 		oldStr = val.String()
 	}
 
-	newStr := transformString(oldStr, tags)
+	newStr, err := r.transformString(oldStr, rule)
 
 	var newVal reflect.Value
 	if val.Kind() == reflect.Ptr {
@@ -142,19 +461,214 @@ func transformValue(tags string, val reflect.Value) reflect.Value {
 		newVal = reflect.ValueOf(newStr)
 	}
 
-	return newVal.Convert(val.Type())
+	return newVal.Convert(val.Type()), err
 }
 
-func transformString(input, tagVal string) string {
-	switch tagVal {
+// transformString applies rule to input. If the rule fails the placeholder is
+// returned along with the error, so a broken rule never leaks the input.
+func (r *Redactor) transformString(input string, rule Rule) (string, error) {
+	rule = r.resolveRule(rule)
+	if input == "" && rule.HasFlag("omitempty") {
+		return input, nil
+	}
+
+	switch rule.Name {
 	case "snapshot":
-		return input
+		return input, nil
+	case "omit":
+		return "", nil
 	default:
-		redactor, ok := redactors[tagVal]
+		redactor, ok := r.lookupRedactor(rule.Name)
 		if !ok {
-			return RedactStrConst
+			if _, isNumber := numberRedactors[rule.Name]; isNumber && r.strict && !rule.inherited {
+				return r.placeholder, fmt.Errorf("%w: rule %s only applies to numbers", ErrUnsupportedKind, rule.Name)
+			}
+			return r.placeholder, nil
+		}
+
+		output, err := redactor(input, rule.Args)
+		if err != nil {
+			return r.placeholder, fmt.Errorf("rule %s: %w", rule.Name, err)
 		}
+		return output, nil
+	}
+}
+
+// transformBytes applies rule to a byte slice as if it was a string. A changed
+// value gets a new backing array, and the old one is zeroed if the Redactor was
+// created with WithWipeBytes. A json.RawMessage is set to the result encoded as
+// a JSON string, so it stays valid JSON.
+func (r *Redactor) transformBytes(val reflect.Value, rule Rule) error {
+	if val.IsNil() || !val.CanSet() {
+		return nil
+	}
+
+	input := val.Bytes()
+	output, err := r.transformString(string(input), rule)
+	if output == string(input) {
+		return err
+	}
+
+	if val.Type() == rawMessageType {
+		quoted, _ := json.Marshal(output)
+		output = string(quoted)
+	}
+	if r.wipeBytes {
+		for i := range input {
+			input[i] = 0
+		}
+	}
+	val.SetBytes([]byte(output))
+	return err
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// transformByteArray applies rule to a byte array as if it was a string. The
+// array can not change its length, so a changed value is cut off or padded
+// with zeros to fit.
+func (r *Redactor) transformByteArray(val reflect.Value, rule Rule) error {
+	input := make([]byte, val.Len())
+	for i := range input {
+		input[i] = byte(val.Index(i).Uint())
+	}
+
+	output, err := r.transformString(string(input), rule)
+	if output == string(input) {
+		return err
+	}
+
+	for i := range input {
+		var b byte
+		if i < len(output) {
+			b = output[i]
+		}
+		val.Index(i).SetUint(uint64(b))
+	}
+	return err
+}
+
+// transformScalar applies rule to a bool or number in place. Numbers are kept
+// by "snapshot" and changed by the numeric rules, e.g. round; "omit" sets them
+// to zero and every other rule to the numeric placeholder. Bools are set to
+// false unless kept.
+func (r *Redactor) transformScalar(val reflect.Value, rule Rule) error {
+	tagged := rule.Name != "" && !rule.inherited
+	rule = r.resolveRule(rule)
+	if rule.Name == "snapshot" || (val.IsZero() && rule.HasFlag("omitempty")) {
+		return nil
+	}
+
+	if rule.Name == "omit" {
+		val.Set(reflect.Zero(val.Type()))
+		return nil
+	}
+	if val.Kind() == reflect.Bool {
+		val.SetBool(false)
+		return nil
+	}
+
+	redactor, ok := numberRedactors[rule.Name]
+	if !ok {
+		r.setNumericPlaceholder(val)
+		if _, isString := r.lookupRedactor(rule.Name); isString && tagged && r.strict {
+			return fmt.Errorf("%w: rule %s does not apply to numbers", ErrUnsupportedKind, rule.Name)
+		}
+		return nil
+	}
+	if err := redactor(val, rule.Args); err != nil {
+		r.setNumericPlaceholder(val)
+		return fmt.Errorf("rule %s: %w", rule.Name, err)
+	}
+	return nil
+}
+
+// setNumericPlaceholder sets val to the numeric placeholder, or to zero if the
+// placeholder does not fit the type of val.
+func (r *Redactor) setNumericPlaceholder(val reflect.Value) {
+	n := r.numericPlaceholder
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if val.OverflowInt(n) {
+			n = 0
+		}
+		val.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n < 0 || val.OverflowUint(uint64(n)) {
+			n = 0
+		}
+		val.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		val.SetFloat(float64(n))
+	}
+}
+
+// CheckRule returns an error wrapping ErrUnknownRule if rule, or its keys= or
+// values= rule, names a rule that is neither reserved nor registered on r. It
+// is what strict mode reports for tags.
+func (r *Redactor) CheckRule(rule Rule) error {
+	return r.checkRule(rule)
+}
+
+// checkRule returns ErrUnknownRule if rule, or its keys= or values= rule,
+// names a rule that is neither reserved nor registered.
+func (r *Redactor) checkRule(rule Rule) error {
+	for _, rl := range []*Rule{&rule, rule.Keys, rule.Values} {
+		if rl == nil || isReservedRule(rl.Name) {
+			continue
+		}
+		if _, ok := numberRedactors[rl.Name]; ok {
+			continue
+		}
+		if _, ok := r.lookupRedactor(rl.Name); !ok {
+			return fmt.Errorf("%w %q", ErrUnknownRule, rl.Name)
+		}
+	}
+	return nil
+}
+
+// resolveRule replaces the name and arguments of an untagged rule with the
+// default rule. Flags set on the field are kept. "inherit" and "reset" left
+// over where there is nothing to inherit from resolve like an untagged rule.
+func (r *Redactor) resolveRule(rule Rule) Rule {
+	if rule.Name != "" && rule.Name != "inherit" && rule.Name != "reset" {
+		return rule
+	}
+
+	resolved := r.defaultRule
+	if rule.Flags != nil {
+		resolved.Flags = rule.Flags
+	}
+	return resolved
+}
+
+// inheritRule returns the rule for a field tagged with rule inside a struct
+// redacted with parent. Untagged fields and fields tagged "inherit" take the
+// parent rule, "reset" goes back to the default rule and any other rule
+// overrides the parent. Flags set on the field replace those of the parent.
+func inheritRule(rule, parent Rule) Rule {
+	switch rule.Name {
+	case "", "inherit":
+		inherited := parent
+		if rule.Flags != nil {
+			inherited.Flags, inherited.Keys, inherited.Values = rule.Flags, rule.Keys, rule.Values
+		}
+		inherited.inherited = inherited.inherited || parent.Name != ""
+		return inherited
+	case "reset":
+		rule.Name, rule.Args, rule.inherited = "", Args{}, false
+		return rule
+	default:
+		return rule
+	}
+}
 
-		return redactor(input)
+// isReservedRule reports whether name is handled by the walker itself rather
+// than by a registered redactor.
+func isReservedRule(name string) bool {
+	switch name {
+	case "", "snapshot", "inherit", "reset", "omit":
+		return true
 	}
+	return false
 }
@@ -32,6 +32,21 @@ type TestStructEmbed struct {
 	data TestStruct `redact:"snapshot"`
 }
 
+type testStructInner struct {
+	Secret string
+	ID     string `redact:"snapshot"`
+	token  string
+}
+
+type TestStructPromoted struct {
+	testStructInner
+	*testStructList
+}
+
+type testStructList struct {
+	Data []string
+}
+
 type TestMaps struct {
 	NonSnapshotMap    map[string]string
 	NonSnapshotMapPtr map[string]*string
@@ -211,4 +226,106 @@ func TestStringTestStructAndEmbed(t *testing.T) {
 		assert.Equal(t, nonSnapshotVal, embed.data.NonSnapshot, "should redact non snapshot value")
 		assert.Equal(t, nonSnapshotPtrVal, *embed.data.NonSnapshotPtr, "should redact non snapshot value")
 	})
+
+	t.Run("Should redact fields promoted from embedded unexported structs", func(t *testing.T) {
+		newPromoted := func() *TestStructPromoted {
+			return &TestStructPromoted{
+				testStructInner: testStructInner{Secret: nonSnapshotVal, ID: snapshotVal, token: nonSnapshotVal},
+				testStructList:  &testStructList{Data: []string{nonSnapshotVal}},
+			}
+		}
+
+		cp, err := redact.Redacted(newPromoted())
+		assert.NoError(t, err, "should not fail to copy struct")
+
+		promoted := newPromoted()
+		err = redact.Snapshot(promoted)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, cp, promoted, "should copy like it redacts")
+		assert.Equal(t, redact.RedactStrConst, promoted.Secret, "should redact promoted fields")
+		assert.Equal(t, snapshotVal, promoted.ID, "should contain snapshot value")
+		assert.Equal(t, nonSnapshotVal, promoted.token, "should leave unexported fields alone")
+		assert.Equal(t, []string{redact.RedactStrConst}, promoted.Data, "should redact fields promoted through pointers")
+	})
+
+	t.Run("Should not redact the original of promoted fields in copies", func(t *testing.T) {
+		original := &TestStructPromoted{testStructList: &testStructList{Data: []string{nonSnapshotVal}}}
+
+		cp, err := redact.Redacted(original)
+		assert.NoError(t, err, "should not fail to copy struct")
+		assert.Equal(t, []string{redact.RedactStrConst}, cp.Data, "should redact the copy")
+		assert.Equal(t, []string{nonSnapshotVal}, original.Data, "should not modify the original")
+	})
+}
+
+func TestSnapshotNonStruct(t *testing.T) {
+	t.Run("Should redact slices, maps and strings behind a pointer", func(t *testing.T) {
+		list := []string{nonSnapshotVal}
+		m := map[string]*TestStruct{"key": {NonSnapshot: nonSnapshotVal, SnapshotStr: snapshotVal}}
+		str := nonSnapshotVal
+
+		assert.NoError(t, redact.Snapshot(&list), "should not fail to redact slice")
+		assert.NoError(t, redact.Snapshot(&m), "should not fail to redact map")
+		assert.NoError(t, redact.Snapshot(&str), "should not fail to redact string")
+
+		assert.Equal(t, redact.RedactStrConst, list[0], "should redact non snapshot value")
+		assert.Equal(t, redact.RedactStrConst, m["key"].NonSnapshot, "should redact non snapshot value")
+		assert.Equal(t, snapshotVal, m["key"].SnapshotStr, "should contain snapshot value")
+		assert.Equal(t, redact.RedactStrConst, str, "should redact non snapshot value")
+	})
+
+	t.Run("Should fail for non pointers", func(t *testing.T) {
+		err := redact.Snapshot(TestStruct{})
+		assert.Error(t, err, "should fail to redact a value that is not a pointer")
+	})
+}
+
+func TestRedacted(t *testing.T) {
+	t.Run("Should return the same static type for values", func(t *testing.T) {
+		ptrVal := nonSnapshotVal
+		tStruct := TestStruct{
+			NonSnapshot:    nonSnapshotVal,
+			NonSnapshotPtr: &ptrVal,
+			SnapshotStr:    snapshotVal,
+		}
+
+		redacted, err := redact.Redacted(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, snapshotVal, redacted.SnapshotStr, "should contain snapshot value")
+		assert.Equal(t, redact.RedactStrConst, redacted.NonSnapshot, "should redact non snapshot value")
+		assert.Equal(t, redact.RedactStrConst, *redacted.NonSnapshotPtr, "should redact non snapshot pointer value")
+		assert.Equal(t, nonSnapshotVal, tStruct.NonSnapshot, "should not modify the original")
+		assert.Equal(t, nonSnapshotVal, ptrVal, "should not modify the original")
+	})
+
+	t.Run("Should return the same static type for pointers, slices and maps", func(t *testing.T) {
+		tStruct := &TestStruct{NonSnapshot: nonSnapshotVal, SnapshotStr: snapshotVal}
+
+		redactedPtr, err := redact.Redacted(tStruct)
+		assert.NoError(t, err, "should not fail to redact pointer")
+		assert.Equal(t, redact.RedactStrConst, redactedPtr.NonSnapshot, "should redact non snapshot value")
+		assert.Equal(t, nonSnapshotVal, tStruct.NonSnapshot, "should not modify the original")
+
+		redactedList, err := redact.Redacted([]*TestStruct{tStruct})
+		assert.NoError(t, err, "should not fail to redact slice")
+		assert.Equal(t, redact.RedactStrConst, redactedList[0].NonSnapshot, "should redact non snapshot value")
+		assert.Equal(t, snapshotVal, redactedList[0].SnapshotStr, "should contain snapshot value")
+
+		redactedMap, err := redact.Redacted(map[string]string{"key": nonSnapshotVal})
+		assert.NoError(t, err, "should not fail to redact map")
+		assert.Equal(t, redact.RedactStrConst, redactedMap["key"], "should redact non snapshot value")
+	})
+
+	t.Run("Should return the zero value for nil", func(t *testing.T) {
+		redacted, err := redact.Redacted[*TestStruct](nil)
+		assert.NoError(t, err)
+		assert.Nil(t, redacted)
+
+		var iface interface{}
+		redactedIface, err := redact.Redacted(iface)
+		assert.NoError(t, err)
+		assert.Nil(t, redactedIface)
+	})
 }
@@ -0,0 +1,81 @@
+package redact
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Redactable is implemented by types that know how to redact themselves, such
+// as a Money value that keeps its currency. The walker calls Redact instead of
+// descending into the value and stores the result in its place, so the result
+// must be of the same type. rule is the rule of the value after inheritance and
+// defaults, e.g. Name is "snapshot" for a value that should be kept.
+type Redactable interface {
+	Redact(rule Rule) any
+}
+
+// InPlaceRedactable is implemented by types that redact themselves in place,
+// usually with a pointer receiver. If RedactInPlace fails the value is set to
+// its zero value and the error is reported.
+//
+// Copy calls RedactInPlace on the copy, which only shares unexported fields
+// with the original, so RedactInPlace should replace rather than change what
+// those point to.
+type InPlaceRedactable interface {
+	RedactInPlace(rule Rule) error
+}
+
+var (
+	redactableType        = reflect.TypeOf((*Redactable)(nil)).Elem()
+	inPlaceRedactableType = reflect.TypeOf((*InPlaceRedactable)(nil)).Elem()
+)
+
+// isRedactable reports whether values of type t, or pointers to them, redact
+// themselves.
+func isRedactable(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return t.Implements(redactableType) || t.Implements(inPlaceRedactableType) ||
+		pt.Implements(redactableType) || pt.Implements(inPlaceRedactableType)
+}
+
+// redactSelf lets the value ifv points to redact itself if it implements
+// Redactable or InPlaceRedactable, with either receiver. It reports whether it
+// did, in which case the walker does not descend into the value.
+func (w *walker) redactSelf(ifv reflect.Value, plan *typePlan, rule Rule) bool {
+	val := ifv.Elem()
+	if !plan.redactable || !val.CanInterface() || !val.CanSet() {
+		return false
+	}
+	// a nil pointer or interface has nothing to redact, and calling a method
+	// on it may panic
+	if (val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface) && val.IsNil() {
+		return true
+	}
+
+	rule = w.resolveRule(rule)
+	// the method set of the pointer holds the methods of both receivers
+	target := val.Interface()
+	if ifv.Type().Implements(inPlaceRedactableType) || ifv.Type().Implements(redactableType) {
+		target = ifv.Interface()
+	}
+
+	switch self := target.(type) {
+	case InPlaceRedactable:
+		if err := self.RedactInPlace(rule); err != nil {
+			val.Set(reflect.Zero(val.Type()))
+			w.fail(val.Type(), err)
+		}
+	case Redactable:
+		result := reflect.ValueOf(self.Redact(rule))
+		switch {
+		case !result.IsValid():
+			val.Set(reflect.Zero(val.Type()))
+		case result.Type().AssignableTo(val.Type()):
+			val.Set(result)
+		default:
+			val.Set(reflect.Zero(val.Type()))
+			w.fail(val.Type(), fmt.Errorf("%w, got %s", ErrRedactableResult, result.Type()))
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package redact_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMoney keeps its currency and rounds its amount when redacted.
+type TestMoney struct {
+	Amount   int64
+	Currency string
+}
+
+func (m TestMoney) Redact(rule redact.Rule) any {
+	if rule.Name == "snapshot" {
+		return m
+	}
+	return TestMoney{Amount: m.Amount / 100 * 100, Currency: m.Currency}
+}
+
+// TestSecretURL hides its password in place.
+type TestSecretURL struct {
+	Host     string
+	Password string
+}
+
+func (u *TestSecretURL) RedactInPlace(redact.Rule) error {
+	if u.Host == "" && u.Password != "" {
+		return errors.New("password without host")
+	}
+	u.Password = "xxxxx"
+	return nil
+}
+
+// TestToken is a string type that redacts itself to its prefix.
+type TestToken string
+
+func (t TestToken) Redact(redact.Rule) any {
+	return TestToken(strings.SplitN(string(t), "_", 2)[0] + "_***")
+}
+
+type TestBadRedactable string
+
+func (TestBadRedactable) Redact(redact.Rule) any {
+	return 42
+}
+
+type TestRedactableStruct struct {
+	Price   TestMoney
+	Kept    TestMoney `redact:"snapshot"`
+	URL     TestSecretURL
+	URLPtr  *TestSecretURL
+	Tokens  []TestToken
+	Wrapped interface{}
+	Missing *TestSecretURL
+}
+
+type TestBadRedactableStruct struct {
+	URL TestSecretURL
+	Bad TestBadRedactable
+}
+
+func TestRedactable(t *testing.T) {
+	t.Run("Should delegate to Redact and RedactInPlace", func(t *testing.T) {
+		tStruct := &TestRedactableStruct{
+			Price:   TestMoney{Amount: 1234, Currency: "EUR"},
+			Kept:    TestMoney{Amount: 1234, Currency: "EUR"},
+			URL:     TestSecretURL{Host: "example.com", Password: "hunter2"},
+			URLPtr:  &TestSecretURL{Host: "example.com", Password: "hunter2"},
+			Tokens:  []TestToken{"sk_live_1234"},
+			Wrapped: TestSecretURL{Host: "example.com", Password: "hunter2"},
+		}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, TestMoney{Amount: 1200, Currency: "EUR"}, tStruct.Price, "should use the value receiver")
+		assert.Equal(t, TestMoney{Amount: 1234, Currency: "EUR"}, tStruct.Kept, "should pass the rule")
+		assert.Equal(t, TestSecretURL{Host: "example.com", Password: "xxxxx"}, tStruct.URL, "should use the pointer receiver")
+		assert.Equal(t, TestSecretURL{Host: "example.com", Password: "xxxxx"}, *tStruct.URLPtr, "should use the pointer receiver")
+		assert.Equal(t, []TestToken{"sk_***"}, tStruct.Tokens, "should delegate for string elements")
+		assert.Equal(t, TestSecretURL{Host: "example.com", Password: "xxxxx"}, tStruct.Wrapped, "should delegate for interfaces")
+		assert.Nil(t, tStruct.Missing, "should skip nil pointers")
+	})
+
+	t.Run("Should redact a copy without changing the original", func(t *testing.T) {
+		tStruct := &TestRedactableStruct{URLPtr: &TestSecretURL{Host: "example.com", Password: "hunter2"}}
+
+		redacted, err := redact.Redacted(tStruct)
+		assert.NoError(t, err, "should not fail to copy struct")
+		assert.Equal(t, "xxxxx", redacted.URLPtr.Password, "should redact the copy")
+		assert.Equal(t, "hunter2", tStruct.URLPtr.Password, "should not modify the original")
+	})
+
+	t.Run("Should zero values that fail to redact themselves", func(t *testing.T) {
+		tStruct := &TestBadRedactableStruct{
+			URL: TestSecretURL{Password: "hunter2"},
+			Bad: "secret",
+		}
+
+		err := redact.Snapshot(tStruct)
+		assert.ErrorIs(t, err, redact.ErrRedactableResult, "should report results of the wrong type")
+
+		var fieldErr *redact.FieldError
+		assert.True(t, errors.As(err, &fieldErr), "should report the field")
+		assert.Equal(t, "URL", fieldErr.Path, "should report the field")
+		assert.Equal(t, TestSecretURL{}, tStruct.URL, "should zero the value on errors")
+		assert.Equal(t, TestBadRedactable(""), tStruct.Bad, "should zero the value on errors")
+	})
+}
@@ -0,0 +1,153 @@
+package redact
+
+import (
+	"sync"
+)
+
+// Redactor holds a redaction configuration: the struct tag key to read, the
+// placeholders written over redacted strings and numbers, the rule applied to
+// untagged fields and its own set of registered redactors, which starts out
+// with the built-in ones. A Redactor is safe for concurrent use.
+type Redactor struct {
+	tagName            string
+	placeholder        string
+	numericPlaceholder int64
+	defaultTag         string
+	wipeBytes          bool
+	strict             bool
+	unexported         bool
+	noGenerated        bool
+
+	// defaultRule is defaultTag parsed by New
+	defaultRule    Rule
+	defaultRuleErr error
+
+	redactorsMu sync.RWMutex
+	redactors   map[string]RedactorFunc
+
+	// fields caches the fields of each struct type and plans caches the plan
+	// of each type, see fieldsOf and planOf
+	fields sync.Map
+	plans  sync.Map
+	// jsonFields caches the fields json writes for each struct type, see
+	// jsonFieldsOf
+	jsonFields sync.Map
+}
+
+// Option configures a Redactor created with New.
+type Option func(*Redactor)
+
+// WithTagName sets the struct tag key the Redactor reads, "redact" by default.
+func WithTagName(name string) Option {
+	return func(r *Redactor) {
+		r.tagName = name
+	}
+}
+
+// WithPlaceholder sets the string that replaces redacted values,
+// RedactStrConst by default.
+func WithPlaceholder(placeholder string) Option {
+	return func(r *Redactor) {
+		r.placeholder = placeholder
+	}
+}
+
+// WithNumericPlaceholder sets the value that replaces redacted numbers, zero
+// by default. Numbers whose type can not hold n, such as unsigned integers for
+// a negative n, are set to zero instead.
+func WithNumericPlaceholder(n int64) Option {
+	return func(r *Redactor) {
+		r.numericPlaceholder = n
+	}
+}
+
+// WithWipeBytes zeroes the backing array of redacted byte slices before they
+// are replaced, so the original bytes do not linger in memory shared with
+// other slices.
+func WithWipeBytes() Option {
+	return func(r *Redactor) {
+		r.wipeBytes = true
+	}
+}
+
+// WithHashKey sets the secret key of the hmac rule, which fails without one.
+// Unlike sha256 the result can not be recomputed from guessed values without
+// the key, while equal values still redact to the same hash.
+func WithHashKey(key []byte) Option {
+	key = append([]byte(nil), key...)
+	return func(r *Redactor) {
+		r.redactors["hmac"] = hmacRedactor(key)
+	}
+}
+
+// WithStrict makes the Redactor report mistakes it otherwise handles
+// silently: tags naming unknown rules, rules used on a kind they do not apply
+// to, values of unsupported kinds such as channels and funcs, and unexported
+// fields it can not write to. The last two are left as they are in either
+// mode, strict mode reports them unless they are tagged "snapshot". Malformed
+// tags are always reported.
+func WithStrict() Option {
+	return func(r *Redactor) {
+		r.strict = true
+	}
+}
+
+// WithUnexported makes Copy, and everything built on it, redact unexported
+// struct fields too. The copy is made and written to with package unsafe,
+// bypassing the usual access rules.
+//
+// Snapshot never writes unexported fields, also with this option: they may
+// refer to state that is shared far beyond the value, such as the
+// *time.Location of a time.Time or the state of a sync.Mutex, which redacting
+// in place would corrupt. In a copy all of it is cloned first.
+func WithUnexported() Option {
+	return func(r *Redactor) {
+		r.unexported = true
+	}
+}
+
+// WithoutGenerated makes the Redactor walk values with reflection also if
+// their type has methods generated by cmd/redactgen, e.g. to check that the
+// generated code is up to date.
+func WithoutGenerated() Option {
+	return func(r *Redactor) {
+		r.noGenerated = true
+	}
+}
+
+// WithDefaultRule sets the tag value used for fields without a tag. By default
+// untagged fields are replaced with the placeholder; WithDefaultRule("snapshot")
+// keeps them instead. A rule that fails to parse is reported by every call to
+// Snapshot and Copy.
+func WithDefaultRule(rule string) Option {
+	return func(r *Redactor) {
+		r.defaultTag = rule
+	}
+}
+
+// New returns a Redactor configured by opts.
+func New(opts ...Option) *Redactor {
+	r := &Redactor{
+		tagName:     tagName,
+		placeholder: RedactStrConst,
+		redactors:   make(map[string]RedactorFunc, len(builtinRedactors)),
+	}
+	for name, fn := range builtinRedactors {
+		r.redactors[name] = fn
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.defaultRule, r.defaultRuleErr = ParseRule(r.defaultTag)
+	return r
+}
+
+// defaultRedactor backs the package level functions.
+var defaultRedactor = New()
+
+// Default returns the Redactor behind the package level functions, for code
+// that takes a *Redactor and should use the redactors registered with
+// RegisterRedactor.
+func Default() *Redactor {
+	return defaultRedactor
+}
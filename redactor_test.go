@@ -0,0 +1,71 @@
+package redact_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestCustomTagStruct struct {
+	Secret   string
+	Public   string `log:"snapshot"`
+	Upper    string `log:"upper"`
+	Ignored  string `redact:"snapshot"`
+	Untagged []string
+}
+
+func TestRedactorOptions(t *testing.T) {
+	t.Run("Should use the configured tag name and placeholder", func(t *testing.T) {
+		r := redact.New(redact.WithTagName("log"), redact.WithPlaceholder("***"))
+
+		tStruct := &TestCustomTagStruct{
+			Secret:   nonSnapshotVal,
+			Public:   snapshotVal,
+			Ignored:  nonSnapshotVal,
+			Untagged: []string{nonSnapshotVal},
+		}
+
+		err := r.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, "***", tStruct.Secret, "should use the configured placeholder")
+		assert.Equal(t, snapshotVal, tStruct.Public, "should read the configured tag name")
+		assert.Equal(t, "***", tStruct.Ignored, "should ignore the default tag name")
+		assert.Equal(t, "***", tStruct.Untagged[0], "should use the configured placeholder in slices")
+	})
+
+	t.Run("Should apply the default rule to untagged fields", func(t *testing.T) {
+		r := redact.New(redact.WithDefaultRule("snapshot"))
+
+		tStruct := &TestStruct{
+			NonSnapshot: nonSnapshotVal,
+			SnapshotStr: snapshotVal,
+		}
+
+		err := r.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, nonSnapshotVal, tStruct.NonSnapshot, "should keep untagged value")
+		assert.Equal(t, snapshotVal, tStruct.SnapshotStr, "should contain snapshot value")
+	})
+
+	t.Run("Should keep registries separate", func(t *testing.T) {
+		r := redact.New(redact.WithTagName("log"))
+		err := r.RegisterRedactor("upper", strings.ToUpper)
+		assert.NoError(t, err, "should not fail to register redactor")
+
+		assert.Contains(t, r.Redactors(), "upper", "should list redactor on the instance")
+		assert.NotContains(t, redact.Redactors(), "upper", "should not list redactor on the default instance")
+
+		tStruct := TestCustomTagStruct{Upper: "value", Secret: nonSnapshotVal}
+		cp, err := r.Copy(tStruct)
+		assert.NoError(t, err, "should not fail to copy struct")
+
+		redacted := cp.(TestCustomTagStruct)
+		assert.Equal(t, "VALUE", redacted.Upper, "should apply the instance redactor")
+		assert.Equal(t, redact.RedactStrConst, redacted.Secret, "should redact non snapshot value")
+		assert.Equal(t, "value", tStruct.Upper, "should not modify the original")
+	})
+}
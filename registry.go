@@ -0,0 +1,111 @@
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrRedactorExists is returned when registering a name that is already taken.
+	ErrRedactorExists = errors.New("redactor already registered")
+	// ErrRedactorNotFound is returned when unregistering a name that is not registered.
+	ErrRedactorNotFound = errors.New("redactor not registered")
+	// ErrInvalidRedactor is returned for an empty or reserved name or a nil function.
+	ErrInvalidRedactor = errors.New("invalid redactor")
+)
+
+// RedactorFunc redacts input according to the arguments of the rule it was
+// registered for, e.g. keep=4 for a field tagged `redact:"mask(keep=4)"`.
+type RedactorFunc func(input string, args Args) (string, error)
+
+// RegisterRedactor makes fn available as the tag value name on the default
+// Redactor, so a field tagged `redact:"name"` is replaced with fn applied to its
+// value. It is safe for concurrent use.
+func RegisterRedactor(name string, fn func(string) string) error {
+	return defaultRedactor.RegisterRedactor(name, fn)
+}
+
+// RegisterRedactorFunc is like RegisterRedactor for a function that takes the
+// arguments of the rule.
+func RegisterRedactorFunc(name string, fn RedactorFunc) error {
+	return defaultRedactor.RegisterRedactorFunc(name, fn)
+}
+
+// UnregisterRedactor removes the redactor registered as name on the default
+// Redactor. Fields tagged with name are replaced with the placeholder afterwards.
+func UnregisterRedactor(name string) error {
+	return defaultRedactor.UnregisterRedactor(name)
+}
+
+// Redactors returns the sorted names of all redactors registered on the
+// default Redactor.
+func Redactors() []string {
+	return defaultRedactor.Redactors()
+}
+
+// RegisterRedactor makes fn available as the tag value name, so a field tagged
+// `redact:"name"` is replaced with fn applied to its value.
+func (r *Redactor) RegisterRedactor(name string, fn func(string) string) error {
+	if fn == nil {
+		return fmt.Errorf("%w: nil function for %q", ErrInvalidRedactor, name)
+	}
+
+	return r.RegisterRedactorFunc(name, func(input string, _ Args) (string, error) {
+		return fn(input), nil
+	})
+}
+
+// RegisterRedactorFunc is like RegisterRedactor for a function that takes the
+// arguments of the rule.
+func (r *Redactor) RegisterRedactorFunc(name string, fn RedactorFunc) error {
+	if !isName(name) || isReservedRule(name) {
+		return fmt.Errorf("%w: name %q is reserved or invalid", ErrInvalidRedactor, name)
+	}
+	if fn == nil {
+		return fmt.Errorf("%w: nil function for %q", ErrInvalidRedactor, name)
+	}
+
+	r.redactorsMu.Lock()
+	defer r.redactorsMu.Unlock()
+
+	if _, ok := r.redactors[name]; ok {
+		return fmt.Errorf("%w: %q", ErrRedactorExists, name)
+	}
+	r.redactors[name] = fn
+	return nil
+}
+
+// UnregisterRedactor removes the redactor registered as name. Fields tagged
+// with name are replaced with the placeholder afterwards.
+func (r *Redactor) UnregisterRedactor(name string) error {
+	r.redactorsMu.Lock()
+	defer r.redactorsMu.Unlock()
+
+	if _, ok := r.redactors[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrRedactorNotFound, name)
+	}
+	delete(r.redactors, name)
+	return nil
+}
+
+// Redactors returns the sorted names of all registered redactors.
+func (r *Redactor) Redactors() []string {
+	r.redactorsMu.RLock()
+	defer r.redactorsMu.RUnlock()
+
+	names := make([]string, 0, len(r.redactors))
+	for name := range r.redactors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Redactor) lookupRedactor(name string) (RedactorFunc, bool) {
+	r.redactorsMu.RLock()
+	defer r.redactorsMu.RUnlock()
+
+	fn, ok := r.redactors[name]
+	return fn, ok
+}
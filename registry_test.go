@@ -0,0 +1,91 @@
+package redact_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestRegistryStruct struct {
+	Upper    string `redact:"test-upper"`
+	Unknown  string `redact:"test-unknown"`
+	Snapshot string `redact:"snapshot"`
+}
+
+func TestRegisterRedactor(t *testing.T) {
+	t.Run("Should apply registered redactors by tag", func(t *testing.T) {
+		err := redact.RegisterRedactor("test-upper", strings.ToUpper)
+		assert.NoError(t, err, "should not fail to register redactor")
+		defer redact.UnregisterRedactor("test-upper")
+
+		tStruct := &TestRegistryStruct{
+			Upper:    "value",
+			Unknown:  nonSnapshotVal,
+			Snapshot: snapshotVal,
+		}
+
+		err = redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, "VALUE", tStruct.Upper, "should apply registered redactor")
+		assert.Equal(t, redact.RedactStrConst, tStruct.Unknown, "should redact unknown tags")
+		assert.Equal(t, snapshotVal, tStruct.Snapshot, "should contain snapshot value")
+		assert.Contains(t, redact.Redactors(), "test-upper", "should list registered redactor")
+	})
+
+	t.Run("Should reject duplicate, reserved and invalid redactors", func(t *testing.T) {
+		err := redact.RegisterRedactor("test-dup", strings.ToUpper)
+		assert.NoError(t, err, "should not fail to register redactor")
+		defer redact.UnregisterRedactor("test-dup")
+
+		err = redact.RegisterRedactor("test-dup", strings.ToLower)
+		assert.ErrorIs(t, err, redact.ErrRedactorExists, "should reject duplicate names")
+
+		err = redact.RegisterRedactor("snapshot", strings.ToLower)
+		assert.ErrorIs(t, err, redact.ErrInvalidRedactor, "should reject reserved names")
+
+		err = redact.RegisterRedactor("inherit", strings.ToLower)
+		assert.ErrorIs(t, err, redact.ErrInvalidRedactor, "should reject reserved names")
+
+		err = redact.RegisterRedactor("", strings.ToLower)
+		assert.ErrorIs(t, err, redact.ErrInvalidRedactor, "should reject empty names")
+
+		err = redact.RegisterRedactor("test-nil", nil)
+		assert.ErrorIs(t, err, redact.ErrInvalidRedactor, "should reject nil functions")
+	})
+
+	t.Run("Should unregister redactors", func(t *testing.T) {
+		err := redact.RegisterRedactor("test-remove", strings.ToUpper)
+		assert.NoError(t, err, "should not fail to register redactor")
+
+		err = redact.UnregisterRedactor("test-remove")
+		assert.NoError(t, err, "should not fail to unregister redactor")
+		assert.NotContains(t, redact.Redactors(), "test-remove", "should not list removed redactor")
+
+		err = redact.UnregisterRedactor("test-remove")
+		assert.ErrorIs(t, err, redact.ErrRedactorNotFound, "should fail to unregister twice")
+	})
+
+	t.Run("Should be safe for concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				name := fmt.Sprintf("test-concurrent-%d", i)
+				assert.NoError(t, redact.RegisterRedactor(name, strings.ToUpper))
+
+				tStruct := &TestRegistryStruct{Upper: "value"}
+				assert.NoError(t, redact.Snapshot(tStruct))
+				redact.Redactors()
+
+				assert.NoError(t, redact.UnregisterRedactor(name))
+			}(i)
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,104 @@
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestScalarStruct struct {
+	AccountNumber int64
+	Balance       float64
+	IsAdmin       bool
+	Attempts      uint8
+	Age           int     `redact:"snapshot"`
+	Verified      bool    `redact:"snapshot"`
+	Salary        int     `redact:"round(1000)"`
+	Debt          int32   `redact:"round(to=100)"`
+	Price         float64 `redact:"round(0.5)"`
+	Count         uint    `redact:"round(10)"`
+	Masked        int     `redact:"mask"`
+	Scores        []int
+	Limits        map[string]float64
+	Flags         []*bool `redact:"snapshot"`
+}
+
+func TestScalarRedaction(t *testing.T) {
+	t.Run("Should zero untagged numbers and bools", func(t *testing.T) {
+		verified := true
+		tStruct := &TestScalarStruct{
+			AccountNumber: 12345678,
+			Balance:       1024.5,
+			IsAdmin:       true,
+			Attempts:      3,
+			Age:           42,
+			Verified:      true,
+			Masked:        7,
+			Scores:        []int{1, 2, 3},
+			Limits:        map[string]float64{"daily": 500},
+			Flags:         []*bool{&verified},
+		}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, int64(0), tStruct.AccountNumber, "should redact non snapshot int")
+		assert.Equal(t, 0.0, tStruct.Balance, "should redact non snapshot float")
+		assert.False(t, tStruct.IsAdmin, "should redact non snapshot bool")
+		assert.Equal(t, uint8(0), tStruct.Attempts, "should redact non snapshot uint")
+		assert.Equal(t, 0, tStruct.Masked, "should redact numbers tagged with string rules")
+		assert.Equal(t, []int{0, 0, 0}, tStruct.Scores, "should redact numbers in slices")
+		assert.Equal(t, 0.0, tStruct.Limits["daily"], "should redact numbers in maps")
+
+		assert.Equal(t, 42, tStruct.Age, "should contain snapshot int")
+		assert.True(t, tStruct.Verified, "should contain snapshot bool")
+		assert.True(t, *tStruct.Flags[0], "should contain snapshot bool pointer")
+	})
+
+	t.Run("Should round tagged numbers", func(t *testing.T) {
+		tStruct := &TestScalarStruct{
+			Salary: 84499,
+			Debt:   -250,
+			Price:  9.74,
+			Count:  15,
+		}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, 84000, tStruct.Salary, "should round to the nearest thousand")
+		assert.Equal(t, int32(-300), tStruct.Debt, "should round halves away from zero")
+		assert.Equal(t, 9.5, tStruct.Price, "should round floats")
+		assert.Equal(t, uint(20), tStruct.Count, "should round unsigned integers")
+	})
+
+	t.Run("Should use the numeric placeholder", func(t *testing.T) {
+		r := redact.New(redact.WithNumericPlaceholder(-1))
+		tStruct := &TestScalarStruct{
+			AccountNumber: 12345678,
+			Balance:       1024.5,
+			Attempts:      3,
+		}
+
+		err := r.Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+
+		assert.Equal(t, int64(-1), tStruct.AccountNumber, "should use the numeric placeholder")
+		assert.Equal(t, -1.0, tStruct.Balance, "should use the numeric placeholder")
+		assert.Equal(t, uint8(0), tStruct.Attempts, "should zero numbers that can not hold the placeholder")
+	})
+
+	t.Run("Should report invalid rounding", func(t *testing.T) {
+		type badRound struct {
+			Small int8 `redact:"round(200)"`
+			Zero  int  `redact:"round(0)"`
+		}
+		tStruct := &badRound{Small: 120, Zero: 5}
+
+		err := redact.Snapshot(tStruct)
+		assert.Error(t, err, "should report invalid rounding")
+		assert.Equal(t, int8(0), tStruct.Small, "should fall back to the placeholder on overflow")
+		assert.Equal(t, 0, tStruct.Zero, "should fall back to the placeholder")
+	})
+}
@@ -0,0 +1,259 @@
+package redact
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// NewHandler returns a slog.Handler that redacts attribute values with the
+// default Redactor before passing records on to next, see Redactor.NewHandler.
+func NewHandler(next slog.Handler) slog.Handler {
+	return defaultRedactor.NewHandler(next)
+}
+
+// NewHandler returns a slog.Handler that redacts attribute values before
+// passing records on to next. Values logged with slog.Any, such as structs,
+// maps, slices and pointers, are redacted by their tags like Copy and turned
+// into groups as LogValue does. Attributes inside groups and those added with
+// WithAttrs are redacted as well. Strings, numbers and other plain values are
+// logged as they are: they have no tags, and were passed to the logger on
+// purpose.
+func (r *Redactor) NewHandler(next slog.Handler) slog.Handler {
+	return &handler{r: r, next: next}
+}
+
+type handler struct {
+	r    *Redactor
+	next slog.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.r.redactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.r.redactAttr(attr)
+	}
+	return &handler{r: h.r, next: h.next.WithAttrs(redacted)}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{r: h.r, next: h.next.WithGroup(name)}
+}
+
+// redactAttr redacts the value of attr.
+func (r *Redactor) redactAttr(attr slog.Attr) slog.Attr {
+	attr.Value = r.redactSlogValue(attr.Value)
+	return attr
+}
+
+func (r *Redactor) redactSlogValue(val slog.Value) slog.Value {
+	switch val.Kind() {
+	case slog.KindGroup:
+		group := val.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, attr := range group {
+			redacted[i] = r.redactAttr(attr)
+		}
+		return slog.GroupValue(redacted...)
+	case slog.KindLogValuer:
+		// values from LogValue are redacted already, when they are resolved
+		if _, ok := val.LogValuer().(logValuer); ok {
+			return val
+		}
+		return r.redactSlogValue(val.Resolve())
+	case slog.KindAny:
+		if _, ok := val.Any().(error); ok {
+			return val
+		}
+		return r.logValue(val.Any())
+	default:
+		return val
+	}
+}
+
+// LogValue returns a slog.LogValuer for a redacted view of v using the default
+// Redactor, see Redactor.LogValue.
+func LogValue(v interface{}) slog.LogValuer {
+	return defaultRedactor.LogValue(v)
+}
+
+// LogValue returns a slog.LogValuer for a redacted view of v. Nothing is done
+// until a handler resolves the value, which only happens if the record is
+// logged, and v is left untouched: the value is made from a redacted copy of
+// v, see Copy. Structs and maps become groups and slices lists of their
+// elements, other values are logged as slog.Value would log them. A pointer,
+// map or slice that leads back to itself is logged as "<cycle>" where it
+// repeats.
+//
+// If redacting fails the values that failed hold the placeholder as usual.
+func (r *Redactor) LogValue(v interface{}) slog.LogValuer {
+	return logValuer{r: r, v: v}
+}
+
+type logValuer struct {
+	r *Redactor
+	v interface{}
+}
+
+func (l logValuer) LogValue() slog.Value {
+	return l.r.logValue(l.v)
+}
+
+// logValue returns the slog.Value of a redacted copy of v.
+func (r *Redactor) logValue(v interface{}) slog.Value {
+	cp, _ := r.copy(v)
+	return slogValueOf(reflect.ValueOf(cp), map[visitKey]bool{})
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// slogValueOf converts val into a slog.Value. ancestors holds the pointers,
+// maps and slices on the way to val, a reference back to one of them is logged
+// as "<cycle>".
+func slogValueOf(val reflect.Value, ancestors map[visitKey]bool) slog.Value {
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if val.IsNil() {
+			return slog.AnyValue(nil)
+		}
+		key := ancestorKey(val)
+		if ancestors[key] {
+			return slog.StringValue("<cycle>")
+		}
+		ancestors[key] = true
+		defer delete(ancestors, key)
+	}
+
+	// values that marshal themselves but not once redacted are logged as the
+	// redacted text
+	if val.IsValid() && isTextKind(val.Type()) && !isMarshalable(val) {
+		return slogTextValue(val)
+	}
+
+	switch val.Kind() {
+	case reflect.Invalid:
+		return slog.AnyValue(nil)
+	case reflect.Ptr:
+		return slogValueOf(val.Elem(), ancestors)
+	case reflect.Interface:
+		if val.IsNil() {
+			return slog.AnyValue(nil)
+		}
+		return slogValueOf(val.Elem(), ancestors)
+	case reflect.Struct:
+		if val.Type() == timeType && val.CanInterface() {
+			return slog.TimeValue(val.Interface().(time.Time))
+		}
+		attrs := make([]slog.Attr, 0, val.NumField())
+		for i := 0; i < val.NumField(); i++ {
+			field := val.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			attrs = append(attrs, slog.Attr{Key: field.Name, Value: slogValueOf(val.Field(i), ancestors)})
+		}
+		return slog.GroupValue(attrs...)
+	case reflect.Map:
+		attrs := make([]slog.Attr, 0, val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			key := iter.Key()
+			name := fmt.Sprint(key)
+			if key.Kind() == reflect.String {
+				name = key.String()
+			}
+			attrs = append(attrs, slog.Attr{Key: name, Value: slogValueOf(iter.Value(), ancestors)})
+		}
+		sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+		return slog.GroupValue(attrs...)
+	case reflect.Slice, reflect.Array:
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			return slogAnyValue(val)
+		}
+		// slog has no lists, so the elements are turned back into plain values
+		elems := make([]interface{}, val.Len())
+		for i := range elems {
+			elems[i] = slogPlain(slogValueOf(val.Index(i), ancestors))
+		}
+		return slog.AnyValue(elems)
+	case reflect.String:
+		return slog.StringValue(val.String())
+	case reflect.Bool:
+		return slog.BoolValue(val.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if val.Type() == durationType {
+			return slog.DurationValue(time.Duration(val.Int()))
+		}
+		return slog.Int64Value(val.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return slog.Uint64Value(val.Uint())
+	case reflect.Float32, reflect.Float64:
+		return slog.Float64Value(val.Float())
+	default:
+		return slogAnyValue(val)
+	}
+}
+
+// slogPlain returns the value v holds, with groups turned into maps, for
+// values that are logged inside a list.
+func slogPlain(v slog.Value) interface{} {
+	if v.Kind() != slog.KindGroup {
+		return v.Any()
+	}
+	group := make(map[string]interface{}, len(v.Group()))
+	for _, attr := range v.Group() {
+		group[attr.Key] = slogPlain(attr.Value)
+	}
+	return group
+}
+
+// slogAnyValue logs val as slog.AnyValue would. Values that can not be
+// turned back into an interface are logged by their type.
+func slogAnyValue(val reflect.Value) slog.Value {
+	if !val.CanInterface() {
+		return slog.StringValue(fmt.Sprintf("<%s>", val.Type()))
+	}
+	return slog.AnyValue(val.Interface())
+}
+
+// isMarshalable reports whether val does not marshal itself, or still does
+// after its contents were redacted. Redacted bytes of a net.IP, for one, are
+// not an address any more.
+func isMarshalable(val reflect.Value) bool {
+	t := val.Type()
+	if !t.Implements(jsonMarshalerType) && !t.Implements(textMarshalerType) || !val.CanInterface() {
+		return true
+	}
+	_, err := json.Marshal(val.Interface())
+	return err == nil
+}
+
+// slogTextValue logs the string or bytes val holds as a string.
+func slogTextValue(val reflect.Value) slog.Value {
+	if val.Kind() == reflect.String {
+		return slog.StringValue(val.String())
+	}
+	text := make([]byte, val.Len())
+	reflect.Copy(reflect.ValueOf(text), val)
+	return slog.StringValue(string(text))
+}
@@ -0,0 +1,149 @@
+package redact_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestLogUser struct {
+	ID       string `redact:"snapshot"`
+	Email    string `redact:"email"`
+	Password string
+	Age      int `redact:"round(10)"`
+	Friend   *TestLogUser
+	Labels   map[string]string `redact:"values=snapshot"`
+	internal string
+}
+
+func newLogUser() *TestLogUser {
+	return &TestLogUser{
+		ID:       "user-1",
+		Email:    "alice@example.com",
+		Password: "hunter2",
+		Age:      42,
+		Labels:   map[string]string{"team": "payments"},
+		internal: "internal",
+	}
+}
+
+// logJSON logs with a redacting JSON handler and returns the decoded record.
+func logJSON(t *testing.T, log func(*slog.Logger)) map[string]interface{} {
+	var buf bytes.Buffer
+	log(slog.New(redact.NewHandler(slog.NewJSONHandler(&buf, nil))))
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record), "should log valid JSON")
+	return record
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("Should redact structs logged with slog.Any", func(t *testing.T) {
+		user := newLogUser()
+		user.Friend = user
+
+		record := logJSON(t, func(logger *slog.Logger) {
+			logger.Info("login", slog.Any("user", user), slog.String("method", "password"))
+		})
+
+		logged := record["user"].(map[string]interface{})
+		assert.Equal(t, "user-1", logged["ID"], "should contain snapshot value")
+		assert.Equal(t, "*****@example.com", logged["Email"], "should apply rules")
+		assert.Equal(t, redact.RedactStrConst, logged["Password"], "should redact untagged fields")
+		assert.Equal(t, float64(40), logged["Age"], "should apply numeric rules")
+		assert.Equal(t, "<cycle>", logged["Friend"], "should stop at cycles")
+		assert.Equal(t, map[string]interface{}{"team": "payments"}, logged["Labels"], "should turn maps into groups")
+		assert.NotContains(t, logged, "internal", "should leave out unexported fields")
+		assert.Equal(t, "password", record["method"], "should log plain values as they are")
+
+		assert.Equal(t, "hunter2", user.Password, "should not modify the original")
+	})
+
+	t.Run("Should redact groups and attributes added to the logger", func(t *testing.T) {
+		record := logJSON(t, func(logger *slog.Logger) {
+			logger.With("owner", newLogUser()).WithGroup("request").Info("login",
+				slog.Group("auth", slog.Any("user", newLogUser())),
+				slog.Any("err", errors.New("wrong password")))
+		})
+
+		owner := record["owner"].(map[string]interface{})
+		assert.Equal(t, redact.RedactStrConst, owner["Password"], "should redact attributes added with With")
+
+		request := record["request"].(map[string]interface{})
+		user := request["auth"].(map[string]interface{})["user"].(map[string]interface{})
+		assert.Equal(t, redact.RedactStrConst, user["Password"], "should redact attributes in groups")
+		assert.Equal(t, "wrong password", request["err"], "should log errors as they are")
+	})
+
+	t.Run("Should log maps with NaN keys", func(t *testing.T) {
+		record := logJSON(t, func(logger *slog.Logger) {
+			logger.Info("stats", slog.Any("counts", map[float64]string{math.NaN(): "a"}))
+		})
+
+		assert.Equal(t, map[string]interface{}{"NaN": redact.RedactStrConst}, record["counts"], "should redact the values")
+	})
+
+	t.Run("Should log values that do not marshal once redacted as text", func(t *testing.T) {
+		type TestLogHost struct {
+			IP     net.IP
+			Server net.IP `redact:"snapshot"`
+		}
+
+		record := logJSON(t, func(logger *slog.Logger) {
+			logger.Info("connect", slog.Any("host", TestLogHost{IP: net.IPv4(10, 0, 0, 1), Server: net.IPv4(10, 0, 0, 2)}))
+		})
+
+		host := record["host"].(map[string]interface{})
+		assert.Equal(t, redact.RedactStrConst, host["IP"], "should log the redacted bytes")
+		assert.Equal(t, "10.0.0.2", host["Server"], "should marshal kept values")
+	})
+}
+
+func TestLogValue(t *testing.T) {
+	t.Run("Should lazily log a redacted view", func(t *testing.T) {
+		user := newLogUser()
+		valuer := redact.LogValue(user)
+		user.Password = "changed"
+
+		value := valuer.LogValue()
+		assert.Equal(t, slog.KindGroup, value.Kind(), "should log structs as groups")
+
+		attrs := map[string]slog.Value{}
+		for _, attr := range value.Group() {
+			attrs[attr.Key] = attr.Value
+		}
+		assert.Equal(t, "user-1", attrs["ID"].String(), "should contain snapshot value")
+		assert.Equal(t, redact.RedactStrConst, attrs["Password"].String(), "should redact when resolved")
+		assert.Equal(t, int64(40), attrs["Age"].Int64(), "should keep numbers numbers")
+		assert.Equal(t, "changed", user.Password, "should not modify the original")
+	})
+
+	t.Run("Should work with plain handlers", func(t *testing.T) {
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, nil)).Info("login", "user", redact.LogValue(newLogUser()))
+
+		assert.Contains(t, buf.String(), `"Password":"NONSNAPSHOT"`, "should redact without the handler")
+		assert.NotContains(t, buf.String(), "hunter2", "should not leak")
+	})
+
+	t.Run("Should stop at cycles through maps and slices", func(t *testing.T) {
+		m := map[string]interface{}{"secret": "hunter2"}
+		m["self"] = m
+		list := []interface{}{"hunter2", nil}
+		list[1] = list
+
+		var buf bytes.Buffer
+		slog.New(slog.NewTextHandler(&buf, nil)).Info("login", "map", redact.LogValue(m), "list", redact.LogValue(list))
+
+		assert.Contains(t, buf.String(), "map.self=<cycle>", "should stop at maps")
+		assert.Contains(t, buf.String(), `list="[NONSNAPSHOT <cycle>]"`, "should stop at slices")
+		assert.NotContains(t, buf.String(), "hunter2", "should not leak")
+	})
+}
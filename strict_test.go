@@ -0,0 +1,78 @@
+package redact_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestStrictStruct struct {
+	Typo     string            `redact:"snapsot"`
+	Round    string            `redact:"round(10)"`
+	Mask     int               `redact:"mask"`
+	Keys     map[string]string `redact:"keys=hashh,values=snapshot"`
+	Done     chan struct{}
+	Callback func()
+	Skipped  chan struct{} `redact:"snapshot"`
+	secret   string
+	internal string `redact:"snapshot"`
+}
+
+func TestStrictMode(t *testing.T) {
+	t.Run("Should keep quiet without strict mode", func(t *testing.T) {
+		tStruct := &TestStrictStruct{Typo: nonSnapshotVal, Done: make(chan struct{})}
+
+		err := redact.Snapshot(tStruct)
+		assert.NoError(t, err, "should not report errors without strict mode")
+		assert.Equal(t, redact.RedactStrConst, tStruct.Typo, "should redact unknown rules")
+	})
+
+	t.Run("Should report mistakes in strict mode", func(t *testing.T) {
+		tStruct := &TestStrictStruct{
+			Typo:   nonSnapshotVal,
+			Round:  nonSnapshotVal,
+			Mask:   42,
+			Keys:   map[string]string{"key": snapshotVal},
+			Done:   make(chan struct{}),
+			secret: nonSnapshotVal,
+		}
+
+		err := redact.New(redact.WithStrict()).Snapshot(tStruct)
+
+		var errs redact.Errors
+		assert.True(t, errors.As(err, &errs), "should return all errors")
+
+		failures := map[string]error{}
+		for _, err := range errs {
+			var fieldErr *redact.FieldError
+			if assert.True(t, errors.As(err, &fieldErr), "should return field errors") {
+				failures[fieldErr.Path] = fieldErr.Err
+			}
+		}
+		assert.Len(t, failures, 7)
+		assert.ErrorIs(t, failures["Typo"], redact.ErrUnknownRule, "should report unknown rules")
+		assert.ErrorIs(t, failures["Keys"], redact.ErrUnknownRule, "should report unknown key rules")
+		assert.ErrorIs(t, failures["Round"], redact.ErrUnsupportedKind, "should report numeric rules on strings")
+		assert.ErrorIs(t, failures["Mask"], redact.ErrUnsupportedKind, "should report string rules on numbers")
+		assert.ErrorIs(t, failures["Done"], redact.ErrUnsupportedKind, "should report channels")
+		assert.ErrorIs(t, failures["Callback"], redact.ErrUnsupportedKind, "should report funcs")
+		assert.ErrorIs(t, failures["secret"], redact.ErrUnexportedField, "should report unexported fields")
+
+		assert.Equal(t, redact.RedactStrConst, tStruct.Typo, "should still redact unknown rules")
+		assert.Equal(t, 0, tStruct.Mask, "should still redact numbers")
+	})
+
+	t.Run("Should report an unknown default rule in strict mode", func(t *testing.T) {
+		err := redact.New(redact.WithStrict(), redact.WithDefaultRule("maks")).Snapshot(&TestStruct{})
+		assert.ErrorIs(t, err, redact.ErrUnknownRule)
+	})
+
+	t.Run("Should accept valid structs in strict mode", func(t *testing.T) {
+		tStruct := &TestStruct{NonSnapshot: nonSnapshotVal, SnapshotStr: snapshotVal}
+
+		err := redact.New(redact.WithStrict()).Snapshot(tStruct)
+		assert.NoError(t, err, "should not report errors")
+	})
+}
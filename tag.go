@@ -0,0 +1,303 @@
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidTag is returned for a redact tag that does not follow the tag
+// grammar.
+var ErrInvalidTag = errors.New("invalid redact tag")
+
+// Rule is a parsed redact tag. A tag names a rule, optionally followed by
+// arguments in parentheses, and then comma separated flags:
+//
+//	`redact:"snapshot"`
+//	`redact:"truncate(8)"`
+//	`redact:"mask(keep=4,from=end),omitempty"`
+//
+// Arguments are either positional or name=value pairs. Flags are either a bare
+// name or name=value. Values containing commas or parentheses can be quoted
+// with single quotes.
+//
+// For maps the keys= and values= flags hold separate rules for the keys and
+// the values, e.g. `redact:"keys=hash,values=snapshot"`. Keys are left as they
+// are unless keys= is set.
+//
+// A rule on a struct, slice, map or pointer field is inherited by everything
+// beneath it that is not tagged itself. `redact:"inherit"` asks for the
+// inherited rule explicitly, e.g. to add flags to it, and `redact:"reset"`
+// goes back to the default rule for the field and everything beneath it.
+//
+// `redact:"omit"` leaves the field out of the output of MarshalJSON. Snapshot
+// and Copy set the strings, numbers and bools beneath it to their zero values.
+type Rule struct {
+	Name  string
+	Args  Args
+	Flags map[string]string
+
+	// Keys and Values are the parsed keys= and values= flags
+	Keys   *Rule
+	Values *Rule
+
+	// inherited is set when the rule was not tagged on the field itself, strict
+	// mode does not report such rules for kinds they do not apply to
+	inherited bool
+}
+
+// HasFlag reports whether the flag name is set on the rule.
+func (r Rule) HasFlag(name string) bool {
+	_, ok := r.Flags[name]
+	return ok
+}
+
+// Args holds the arguments of a rule.
+type Args struct {
+	positional []string
+	named      map[string]string
+}
+
+// Len returns the number of positional arguments.
+func (a Args) Len() int {
+	return len(a.positional)
+}
+
+// Lookup returns the argument called name, falling back to the positional
+// argument at pos. A negative pos only looks up the named argument.
+func (a Args) Lookup(name string, pos int) (string, bool) {
+	if v, ok := a.named[name]; ok {
+		return v, true
+	}
+	if pos >= 0 && pos < len(a.positional) {
+		return a.positional[pos], true
+	}
+	return "", false
+}
+
+// String returns the argument called name or at pos, or def if it is not set.
+func (a Args) String(name string, pos int, def string) string {
+	if v, ok := a.Lookup(name, pos); ok {
+		return v
+	}
+	return def
+}
+
+// Int returns the argument called name or at pos as an int, or def if it is
+// not set.
+func (a Args) Int(name string, pos int, def int) (int, error) {
+	v, ok := a.Lookup(name, pos)
+	if !ok {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return def, fmt.Errorf("argument %s: %q is not an integer", name, v)
+	}
+	return i, nil
+}
+
+// Float returns the argument called name or at pos as a float64, or def if it
+// is not set.
+func (a Args) Float(name string, pos int, def float64) (float64, error) {
+	v, ok := a.Lookup(name, pos)
+	if !ok {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def, fmt.Errorf("argument %s: %q is not a number", name, v)
+	}
+	return f, nil
+}
+
+// Bool returns the argument called name or at pos as a bool, or def if it is
+// not set.
+func (a Args) Bool(name string, pos int, def bool) (bool, error) {
+	v, ok := a.Lookup(name, pos)
+	if !ok {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def, fmt.Errorf("argument %s: %q is not a boolean", name, v)
+	}
+	return b, nil
+}
+
+// ParseRule parses a redact tag value. The empty string parses to the empty
+// Rule, which stands for an untagged field.
+func ParseRule(tag string) (Rule, error) {
+	var rule Rule
+
+	segments, err := splitTopLevel(tag)
+	if err != nil {
+		return Rule{}, invalidTag(tag, err.Error())
+	}
+
+	for i, seg := range segments {
+		seg = strings.TrimSpace(seg)
+		if seg == "" {
+			if len(segments) == 1 {
+				return rule, nil
+			}
+			return Rule{}, invalidTag(tag, "empty segment")
+		}
+
+		// the first segment is the rule, unless it is already a name=value flag
+		if i == 0 && !isFlag(seg) {
+			if err := parseRuleName(seg, &rule); err != nil {
+				return Rule{}, invalidTag(tag, err.Error())
+			}
+			continue
+		}
+
+		name, value := seg, ""
+		if idx := strings.IndexByte(seg, '='); idx >= 0 {
+			name, value = strings.TrimSpace(seg[:idx]), unquote(strings.TrimSpace(seg[idx+1:]))
+		}
+		if !isName(name) {
+			return Rule{}, invalidTag(tag, fmt.Sprintf("invalid flag %q", seg))
+		}
+		if rule.Flags == nil {
+			rule.Flags = map[string]string{}
+		}
+		rule.Flags[name] = value
+	}
+
+	for name, dst := range map[string]**Rule{"keys": &rule.Keys, "values": &rule.Values} {
+		value, ok := rule.Flags[name]
+		if !ok {
+			continue
+		}
+		nested, err := ParseRule(value)
+		if err != nil {
+			return Rule{}, invalidTag(tag, fmt.Sprintf("%s: %v", name, err))
+		}
+		*dst = &nested
+	}
+
+	return rule, nil
+}
+
+// MustParseRule is like ParseRule but panics if the tag does not parse. It
+// simplifies the initialization of variables holding rules, such as those of
+// generated code.
+func MustParseRule(tag string) Rule {
+	rule, err := ParseRule(tag)
+	if err != nil {
+		panic(err)
+	}
+	return rule
+}
+
+func invalidTag(tag, reason string) error {
+	return fmt.Errorf("%w %q: %s", ErrInvalidTag, tag, reason)
+}
+
+// parseRuleName parses `name` or `name(args)` into rule.
+func parseRuleName(seg string, rule *Rule) error {
+	open := strings.IndexByte(seg, '(')
+	if open < 0 {
+		if !isName(seg) {
+			return fmt.Errorf("invalid rule name %q", seg)
+		}
+		rule.Name = seg
+		return nil
+	}
+
+	rule.Name = strings.TrimSpace(seg[:open])
+	if !isName(rule.Name) {
+		return fmt.Errorf("invalid rule name %q", rule.Name)
+	}
+	if !strings.HasSuffix(seg, ")") {
+		return fmt.Errorf("unexpected text after arguments of %q", rule.Name)
+	}
+
+	args, err := splitTopLevel(seg[open+1 : len(seg)-1])
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 && strings.TrimSpace(args[0]) == "" {
+		return nil
+	}
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			return fmt.Errorf("empty argument to %q", rule.Name)
+		}
+		if idx := strings.IndexByte(arg, '='); idx >= 0 && isName(strings.TrimSpace(arg[:idx])) {
+			if rule.Args.named == nil {
+				rule.Args.named = map[string]string{}
+			}
+			rule.Args.named[strings.TrimSpace(arg[:idx])] = unquote(strings.TrimSpace(arg[idx+1:]))
+			continue
+		}
+		rule.Args.positional = append(rule.Args.positional, unquote(arg))
+	}
+	return nil
+}
+
+// splitTopLevel splits s on commas that are neither inside parentheses nor
+// inside single quotes.
+func splitTopLevel(s string) ([]string, error) {
+	var (
+		segments []string
+		depth    int
+		quoted   bool
+		start    int
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth < 0 {
+				return nil, errors.New("unbalanced parentheses")
+			}
+		case c == ',' && depth == 0:
+			segments = append(segments, s[start:i])
+			start = i + 1
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	if depth != 0 {
+		return nil, errors.New("unbalanced parentheses")
+	}
+	return append(segments, s[start:]), nil
+}
+
+// isFlag reports whether seg is a name=value pair outside of any parentheses.
+func isFlag(seg string) bool {
+	eq := strings.IndexByte(seg, '=')
+	open := strings.IndexByte(seg, '(')
+	return eq >= 0 && (open < 0 || eq < open)
+}
+
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '-', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,128 @@
+package redact_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestTagStruct struct {
+	Repeated  string `redact:"test-repeat(times=2,sep='-')"`
+	Empty     string `redact:"snapshot,omitempty"`
+	EmptyMask string `redact:"test-repeat,omitempty"`
+	Invalid   string `redact:"test-repeat(times=2"`
+}
+
+func TestParseRule(t *testing.T) {
+	t.Run("Should parse rule names, arguments and flags", func(t *testing.T) {
+		rule, err := redact.ParseRule("mask(keep=4,from=end),omitempty")
+		assert.NoError(t, err, "should parse tag")
+		assert.Equal(t, "mask", rule.Name)
+		assert.Equal(t, "4", rule.Args.String("keep", -1, ""))
+		assert.Equal(t, "end", rule.Args.String("from", -1, ""))
+		assert.True(t, rule.HasFlag("omitempty"))
+
+		keep, err := rule.Args.Int("keep", 0, 0)
+		assert.NoError(t, err, "should parse integer argument")
+		assert.Equal(t, 4, keep)
+	})
+
+	t.Run("Should parse positional and quoted arguments", func(t *testing.T) {
+		rule, err := redact.ParseRule("truncate(8, ',')")
+		assert.NoError(t, err, "should parse tag")
+		assert.Equal(t, "truncate", rule.Name)
+		assert.Equal(t, 2, rule.Args.Len())
+
+		n, err := rule.Args.Int("n", 0, 0)
+		assert.NoError(t, err, "should parse positional argument")
+		assert.Equal(t, 8, n)
+		assert.Equal(t, ",", rule.Args.String("suffix", 1, ""))
+	})
+
+	t.Run("Should parse tags without a rule name", func(t *testing.T) {
+		rule, err := redact.ParseRule("")
+		assert.NoError(t, err, "should parse empty tag")
+		assert.Equal(t, redact.Rule{}, rule)
+
+		rule, err = redact.ParseRule("keys=mask(keep=2),values=snapshot")
+		assert.NoError(t, err, "should parse flags")
+		assert.Equal(t, "", rule.Name)
+		assert.Equal(t, "mask(keep=2)", rule.Flags["keys"])
+		assert.Equal(t, "snapshot", rule.Flags["values"])
+	})
+
+	t.Run("Should reject malformed tags", func(t *testing.T) {
+		for _, tag := range []string{
+			"mask(keep=4",
+			"mask)keep=4(",
+			"mask(keep=4)x",
+			"mask(,)",
+			"mask,,omitempty",
+			"bad name",
+			"mask('x)",
+		} {
+			_, err := redact.ParseRule(tag)
+			assert.ErrorIs(t, err, redact.ErrInvalidTag, "should reject %q", tag)
+		}
+	})
+}
+
+func TestRuleArguments(t *testing.T) {
+	r := redact.New()
+	err := r.RegisterRedactorFunc("test-repeat", func(input string, args redact.Args) (string, error) {
+		times, err := args.Int("times", 0, 1)
+		if err != nil {
+			return "", err
+		}
+		return strings.Repeat(input, times) + args.String("sep", 1, ""), nil
+	})
+	assert.NoError(t, err, "should not fail to register redactor")
+
+	t.Run("Should pass arguments to redactors", func(t *testing.T) {
+		tStruct := &TestTagStruct{Repeated: "ab"}
+
+		r.Snapshot(tStruct)
+		assert.Equal(t, "abab-", tStruct.Repeated, "should apply arguments")
+	})
+
+	t.Run("Should keep empty values with omitempty", func(t *testing.T) {
+		tStruct := &TestTagStruct{}
+
+		r.Snapshot(tStruct)
+		assert.Equal(t, "", tStruct.Empty, "should keep empty value")
+		assert.Equal(t, "", tStruct.EmptyMask, "should keep empty value")
+		assert.Equal(t, redact.RedactStrConst, tStruct.Invalid, "should redact empty value without omitempty")
+	})
+
+	t.Run("Should report malformed tags per field and redact the field", func(t *testing.T) {
+		tStruct := &TestTagStruct{Invalid: nonSnapshotVal}
+
+		err := r.Snapshot(tStruct)
+		assert.ErrorIs(t, err, redact.ErrInvalidTag, "should report malformed tag")
+		assert.Contains(t, err.Error(), "Invalid", "should name the field")
+		assert.Equal(t, redact.RedactStrConst, tStruct.Invalid, "should fall back to the placeholder")
+	})
+
+	t.Run("Should report redactor errors and redact the field", func(t *testing.T) {
+		type badArg struct {
+			BadArg string `redact:"test-repeat(times=two)"`
+		}
+		tStruct := &badArg{BadArg: nonSnapshotVal}
+
+		err := r.Snapshot(tStruct)
+		assert.Error(t, err, "should report redactor error")
+		assert.Contains(t, err.Error(), "BadArg", "should name the field")
+		assert.Equal(t, redact.RedactStrConst, tStruct.BadArg, "should fall back to the placeholder")
+	})
+
+	t.Run("Should report an invalid default rule", func(t *testing.T) {
+		r := redact.New(redact.WithDefaultRule("snapshot("))
+		tStruct := &TestStruct{NonSnapshot: nonSnapshotVal}
+
+		err := r.Snapshot(tStruct)
+		assert.ErrorIs(t, err, redact.ErrInvalidTag, "should report invalid default rule")
+		assert.Equal(t, redact.RedactStrConst, tStruct.NonSnapshot, "should fall back to the placeholder")
+	})
+}
@@ -0,0 +1,78 @@
+package redact_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samkreter/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestUnexportedStruct struct {
+	Public  string `redact:"snapshot"`
+	secret  string
+	token   *string
+	inner   TestStruct
+	list    []string
+	kept    string `redact:"snapshot"`
+	created time.Time
+}
+
+func TestUnexportedFields(t *testing.T) {
+	t.Run("Should redact unexported fields in copies", func(t *testing.T) {
+		token := nonSnapshotVal
+		created := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+		tStruct := &TestUnexportedStruct{
+			Public:  snapshotVal,
+			secret:  nonSnapshotVal,
+			token:   &token,
+			inner:   TestStruct{NonSnapshot: nonSnapshotVal, SnapshotStr: snapshotVal},
+			list:    []string{nonSnapshotVal},
+			kept:    snapshotVal,
+			created: created,
+		}
+
+		cp, err := redact.New(redact.WithUnexported()).Copy(tStruct)
+		assert.NoError(t, err, "should not fail to copy struct")
+
+		redacted := cp.(*TestUnexportedStruct)
+		assert.Equal(t, snapshotVal, redacted.Public, "should contain snapshot value")
+		assert.Equal(t, redact.RedactStrConst, redacted.secret, "should redact unexported strings")
+		assert.Equal(t, redact.RedactStrConst, *redacted.token, "should redact unexported pointers")
+		assert.Equal(t, redact.RedactStrConst, redacted.inner.NonSnapshot, "should redact unexported structs")
+		assert.Equal(t, snapshotVal, redacted.inner.SnapshotStr, "should contain snapshot value")
+		assert.Equal(t, []string{redact.RedactStrConst}, redacted.list, "should redact unexported slices")
+		assert.Equal(t, snapshotVal, redacted.kept, "should contain snapshot value")
+		assert.True(t, redacted.created.IsZero(), "should redact the internals of other types")
+
+		assert.Equal(t, nonSnapshotVal, tStruct.secret, "should not modify the original")
+		assert.Equal(t, nonSnapshotVal, token, "should not modify values behind unexported pointers")
+		assert.Equal(t, nonSnapshotVal, tStruct.inner.NonSnapshot, "should not modify the original")
+		assert.Equal(t, []string{nonSnapshotVal}, tStruct.list, "should not modify the original")
+		assert.Equal(t, created, tStruct.created, "should not modify the original")
+		assert.Equal(t, "UTC", time.UTC.String(), "should not modify shared state")
+	})
+
+	t.Run("Should not write unexported fields in place", func(t *testing.T) {
+		tStruct := &TestUnexportedStruct{secret: nonSnapshotVal, Public: snapshotVal}
+
+		err := redact.New(redact.WithUnexported()).Snapshot(tStruct)
+		assert.NoError(t, err, "should not fail to redact struct")
+		assert.Equal(t, nonSnapshotVal, tStruct.secret, "should leave unexported fields in place")
+	})
+
+	t.Run("Should leave unexported fields without the option", func(t *testing.T) {
+		tStruct := TestUnexportedStruct{secret: nonSnapshotVal}
+
+		redacted, err := redact.Redacted(tStruct)
+		assert.NoError(t, err, "should not fail to copy struct")
+		assert.Equal(t, nonSnapshotVal, redacted.secret, "should leave unexported fields")
+	})
+
+	t.Run("Should not report reachable unexported fields in strict mode", func(t *testing.T) {
+		tStruct := &TestUnexportedStruct{secret: nonSnapshotVal}
+
+		_, err := redact.New(redact.WithUnexported(), redact.WithStrict()).Copy(tStruct)
+		assert.NoError(t, err, "should not report unexported fields")
+	})
+}
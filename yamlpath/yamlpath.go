@@ -0,0 +1,443 @@
+// Package yamlpath redacts YAML documents, such as configuration files and
+// Kubernetes manifests, on their yaml.Node trees, so comments, key order,
+// anchors and quoting survive and the output decodes with yaml.v3 as the input
+// did. Rules are chosen by path, as in package jsonpath, or by key name:
+//
+//	$.spec.template.spec.containers[*].env[*].value: redact
+//	*password*: redact
+//	$.metadata.annotations: omit
+//
+// A rule that does not start with $ is a glob pattern matched against the
+// lower case keys of mappings at any depth, see path.Match. The part after the
+// colon is a redact tag, so it names any rule registered on the
+// redact.Redactor in use. "redact" stands for the default rule and "omit"
+// removes the key and its value.
+//
+// Rules apply to everything beneath the value they lead to, the last matching
+// rule winning, and the keys of merged mappings (<<) are matched as if they
+// were written where they are merged. An alias is kept if the value it refers
+// to redacts the same at both places, and otherwise replaced with a redacted
+// copy of that value, so an alias never shows more than a rule allows.
+package yamlpath
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/samkreter/redact"
+	"github.com/samkreter/redact/internal/pathrule"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrInvalidPath is returned for a path that does not follow the path
+	// grammar, or a bad key pattern.
+	ErrInvalidPath = pathrule.ErrInvalidPath
+	// ErrInvalidRule is returned for a rule that is not a path or key pattern
+	// followed by a colon and a redact tag.
+	ErrInvalidRule = pathrule.ErrInvalidRule
+	// ErrExcessiveAliasing is returned for a document that redacts far more
+	// values through aliases than it holds, such as a "billion laughs"
+	// document. yaml.v3 refuses the same documents when decoding them into
+	// values.
+	ErrExcessiveAliasing = errors.New("yamlpath: document contains excessive aliasing")
+)
+
+// Redactor redacts YAML documents with rules chosen by path or key name. It is
+// safe for concurrent use.
+type Redactor struct {
+	r         *redact.Redactor
+	rules     pathrule.Rules
+	allowlist bool
+	indent    int
+}
+
+// Option configures a Redactor created with New.
+type Option func(*Redactor)
+
+// WithRedactor sets the redact.Redactor whose registered redactors,
+// placeholder and default rule are used, redact.Default() by default.
+func WithRedactor(r *redact.Redactor) Option {
+	return func(y *Redactor) {
+		y.r = r
+	}
+}
+
+// Allowlist redacts every scalar with the default rule unless a rule leads to
+// it, as Snapshot does for untagged fields. Values are then kept with the
+// "snapshot" rule.
+func Allowlist() Option {
+	return func(y *Redactor) {
+		y.allowlist = true
+	}
+}
+
+// WithIndent sets the number of spaces Redact indents nested values with, 2
+// by default. yaml.v3 does not record the indentation of the input.
+func WithIndent(spaces int) Option {
+	return func(y *Redactor) {
+		y.indent = spaces
+	}
+}
+
+// New returns a Redactor for rules, each a path or a key pattern and a redact
+// tag separated by a colon. Rules naming a redactor that is not registered are
+// rejected.
+func New(rules []string, opts ...Option) (*Redactor, error) {
+	y := &Redactor{r: redact.Default(), indent: 2}
+	for _, opt := range opts {
+		opt(y)
+	}
+
+	for _, text := range rules {
+		rule, err := pathrule.Parse(y.r, text, true)
+		if err != nil {
+			return nil, err
+		}
+		y.rules = append(y.rules, rule)
+	}
+	return y, nil
+}
+
+// MustNew is like New but panics if a rule does not parse. It simplifies
+// initializing package level variables.
+func MustNew(rules []string, opts ...Option) *Redactor {
+	y, err := New(rules, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return y
+}
+
+// Redact returns the YAML stream doc with every document redacted, see
+// RedactNode. If a value fails to redact Redact returns nil and the errors,
+// as redact.Copy does.
+func (y *Redactor) Redact(doc []byte) ([]byte, error) {
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(doc))
+	for {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, &node)
+	}
+
+	var errs redact.Errors
+	for _, node := range docs {
+		if err := y.RedactNode(node); err != nil {
+			errs = append(errs, err.(redact.Errors)...)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var buf bytes.Buffer
+	for _, node := range docs {
+		untagMerges(node)
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(y.indent)
+	for _, node := range docs {
+		if err := enc.Encode(node); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RedactNode redacts the document or value node in place. Scalars are
+// replaced by their redacted values, keeping their style; a string that would
+// read as another type is quoted when encoded. Numbers and bools are redacted
+// as such, other scalars as strings.
+//
+// The errors are returned as redact.Errors. Values that failed to redact hold
+// the placeholder, as they do after Snapshot. If the errors include
+// ErrExcessiveAliasing redacting stopped early, and node must not be shown.
+func (y *Redactor) RedactNode(node *yaml.Node) error {
+	w := &walk{y: y, originals: map[*yaml.Node]*yaml.Node{}, removed: map[*yaml.Node]bool{}}
+	w.saveAnchors(node)
+
+	root := node
+	if node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+		root = node.Content[0]
+	}
+	if !w.redact(root, nil, pathrule.Base(y.allowlist)) {
+		// an omitted document is left empty
+		*root = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+	if len(w.errs) > 0 {
+		return w.errs
+	}
+	return nil
+}
+
+// walk is the state of redacting one document.
+type walk struct {
+	y *Redactor
+
+	// originals holds a copy of each anchored node as it was before it was
+	// redacted, which aliases to it are redacted from
+	originals map[*yaml.Node]*yaml.Node
+	// removed holds the anchored nodes that were omitted
+	removed map[*yaml.Node]bool
+
+	// nodes counts the nodes redacted, aliased those of them redacted in
+	// copies made for aliases, inAlias is set while making one
+	nodes, aliased int
+	inAlias        int
+	excessive      bool
+
+	errs redact.Errors
+}
+
+func (w *walk) saveAnchors(node *yaml.Node) {
+	if node.Anchor != "" {
+		w.originals[node] = clone(node)
+	}
+	for _, child := range node.Content {
+		w.saveAnchors(child)
+	}
+}
+
+// redact redacts node at the location steps with the rules leading to it, or
+// with inherited. It reports false if node is to be removed.
+func (w *walk) redact(node *yaml.Node, steps []pathrule.Step, inherited redact.Rule) bool {
+	if !w.count() {
+		return true
+	}
+
+	rule := w.y.rules.Resolve(steps, inherited)
+	if rule.Name == "omit" {
+		w.remove(node)
+		return false
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			w.redact(child, steps, rule)
+		}
+	case yaml.MappingNode:
+		content := make([]*yaml.Node, 0, len(node.Content))
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind == yaml.ScalarNode && key.ShortTag() == "!!merge" {
+				w.redactMerge(value, steps, rule)
+				content = append(content, key, value)
+				continue
+			}
+			if w.redact(value, append(steps, pathrule.Key(key.Value)), rule) {
+				content = append(content, key, value)
+			}
+		}
+		node.Content = content
+	case yaml.SequenceNode:
+		content := make([]*yaml.Node, 0, len(node.Content))
+		for i, child := range node.Content {
+			if w.redact(child, append(steps, pathrule.Index(i)), rule) {
+				content = append(content, child)
+			}
+		}
+		node.Content = content
+	case yaml.AliasNode:
+		w.redactAlias(node, steps, rule)
+	case yaml.ScalarNode:
+		if rule.Name != "snapshot" {
+			if err := w.y.redactScalar(node, rule); err != nil {
+				w.errs = append(w.errs, fmt.Errorf("%s: %w", pathrule.Format(steps), err))
+			}
+		}
+	}
+	return true
+}
+
+// redactMerge redacts the mappings merged into the mapping at steps, which
+// are an alias, a mapping or a sequence of them.
+func (w *walk) redactMerge(value *yaml.Node, steps []pathrule.Step, rule redact.Rule) {
+	if value.Kind != yaml.SequenceNode {
+		w.redact(value, steps, rule)
+		return
+	}
+	for _, child := range value.Content {
+		w.redact(child, steps, rule)
+	}
+}
+
+// redactAlias redacts a copy of the value alias refers to at the location of
+// the alias. If the copy differs from the value as it was redacted where it is
+// anchored, or that value was omitted, the alias is replaced with the copy.
+func (w *walk) redactAlias(alias *yaml.Node, steps []pathrule.Step, rule redact.Rule) {
+	original, ok := w.originals[alias.Alias]
+	if !ok {
+		return
+	}
+
+	cp := clone(original)
+	w.inAlias++
+	w.redact(cp, steps, rule)
+	w.inAlias--
+	if w.excessive || !w.removed[alias.Alias] && equal(cp, alias.Alias) {
+		return
+	}
+
+	clearAnchors(cp)
+	cp.HeadComment, cp.LineComment, cp.FootComment = alias.HeadComment, alias.LineComment, alias.FootComment
+	*alias = *cp
+}
+
+// count counts a node about to be redacted and reports whether the walk may
+// go on. Each alias is redacted in a copy of the value it refers to, so nested
+// aliases multiply the work; the walk stops once the share of nodes redacted
+// through aliases is beyond what yaml.v3 allows when decoding.
+func (w *walk) count() bool {
+	if w.excessive {
+		return false
+	}
+	w.nodes++
+	if w.inAlias > 0 {
+		w.aliased++
+	}
+	if w.aliased > 100 && w.nodes > 1000 && float64(w.aliased)/float64(w.nodes) > allowedAliasRatio(w.nodes) {
+		w.excessive = true
+		w.errs = append(w.errs, ErrExcessiveAliasing)
+		return false
+	}
+	return true
+}
+
+// allowedAliasRatio returns the share of nodes that may be redacted through
+// aliases, as yaml.v3 computes it: 99% for documents of up to 400,000 nodes,
+// falling to 10% for 4,000,000 nodes.
+func allowedAliasRatio(nodes int) float64 {
+	const low, high = 400000, 4000000
+	switch {
+	case nodes <= low:
+		return 0.99
+	case nodes >= high:
+		return 0.10
+	default:
+		return 0.99 - 0.89*float64(nodes-low)/float64(high-low)
+	}
+}
+
+// remove records the anchored nodes beneath node as omitted.
+func (w *walk) remove(node *yaml.Node) {
+	if node.Anchor != "" {
+		w.removed[node] = true
+	}
+	for _, child := range node.Content {
+		w.remove(child)
+	}
+}
+
+// redactScalar applies rule to the value of a scalar node.
+func (y *Redactor) redactScalar(node *yaml.Node, rule redact.Rule) error {
+	switch node.ShortTag() {
+	case "!!null":
+		return nil
+	case "!!bool":
+		if b, err := strconv.ParseBool(node.Value); err == nil {
+			redacted := b
+			err := y.r.Apply(&redacted, rule)
+			if redacted != b {
+				node.Value = strconv.FormatBool(redacted)
+			}
+			return err
+		}
+	case "!!int":
+		if i, err := strconv.ParseInt(strings.ReplaceAll(node.Value, "_", ""), 0, 64); err == nil {
+			redacted := i
+			err := y.r.Apply(&redacted, rule)
+			if redacted != i {
+				node.Value = strconv.FormatInt(redacted, 10)
+			}
+			return err
+		}
+	case "!!float":
+		if f, err := strconv.ParseFloat(strings.ReplaceAll(node.Value, "_", ""), 64); err == nil {
+			redacted := f
+			err := y.r.Apply(&redacted, rule)
+			if redacted != f {
+				node.Value = formatFloat(redacted)
+			}
+			return err
+		}
+	}
+
+	// strings, and scalars that did not parse as their type
+	redacted := node.Value
+	err := y.r.Apply(&redacted, rule)
+	if redacted != node.Value {
+		node.Value = redacted
+		if strings.HasPrefix(node.Tag, "!!") || node.Tag == "" {
+			node.Tag = "!!str"
+		}
+	}
+	return err
+}
+
+// formatFloat formats f so that it reads as a float, not an int.
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+// clone returns a deep copy of node. Aliases in the copy refer to the same
+// nodes as those in node.
+func clone(node *yaml.Node) *yaml.Node {
+	cp := *node
+	cp.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		cp.Content[i] = clone(child)
+	}
+	return &cp
+}
+
+// untagMerges drops the !!merge tag of merge keys, which yaml.v3 would
+// otherwise write out as "!!merge <<". It is implied by the key.
+func untagMerges(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!merge" {
+		node.Tag = ""
+	}
+	for _, child := range node.Content {
+		untagMerges(child)
+	}
+}
+
+func clearAnchors(node *yaml.Node) {
+	node.Anchor = ""
+	for _, child := range node.Content {
+		clearAnchors(child)
+	}
+}
+
+// equal reports whether a and b hold the same values, ignoring comments and
+// styles.
+func equal(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || a.ShortTag() != b.ShortTag() || a.Value != b.Value ||
+		a.Alias != b.Alias || len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if !equal(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
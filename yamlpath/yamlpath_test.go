@@ -0,0 +1,168 @@
+package yamlpath_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samkreter/redact"
+	"github.com/samkreter/redact/yamlpath"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+const testConfig = `# service configuration
+name: billing # the service name
+database:
+  host: db.internal
+  port: 5432
+  password: hunter2 # rotate monthly
+  replicas: [db-1, db-2]
+defaults: &defaults
+  apiToken: "abc123"
+  retries: 3
+prod:
+  <<: *defaults
+  region: eu-west-1
+notes: |
+  line one
+  line two
+`
+
+func TestRedact(t *testing.T) {
+	t.Run("Should redact by path and key pattern and keep the layout", func(t *testing.T) {
+		y, err := yamlpath.New([]string{
+			"*password*: redact",
+			"$.database.port: round(1000)",
+			"$.notes: omit",
+		})
+		assert.NoError(t, err)
+
+		out, err := y.Redact([]byte(testConfig))
+		assert.NoError(t, err)
+		assert.Equal(t, `# service configuration
+name: billing # the service name
+database:
+  host: db.internal
+  port: 5000
+  password: NONSNAPSHOT # rotate monthly
+  replicas: [db-1, db-2]
+defaults: &defaults
+  apiToken: "abc123"
+  retries: 3
+prod:
+  <<: *defaults
+  region: eu-west-1
+`, string(out), "should keep comments, order, anchors and styles")
+	})
+
+	t.Run("Should replace aliases that would show more than a rule allows", func(t *testing.T) {
+		y := yamlpath.MustNew([]string{"$.prod.apiToken: last4"})
+
+		out, err := y.Redact([]byte(testConfig))
+		assert.NoError(t, err)
+
+		var decoded struct {
+			Defaults map[string]interface{}
+			Prod     map[string]interface{}
+		}
+		assert.NoError(t, yaml.Unmarshal(out, &decoded), "should round trip through yaml.v3")
+		assert.Equal(t, "abc123", decoded.Defaults["apiToken"], "should keep the anchored value")
+		assert.Equal(t, "**c123", decoded.Prod["apiToken"], "should redact the merged copy")
+		assert.Equal(t, 3, decoded.Prod["retries"])
+		assert.NotContains(t, string(out), "*defaults", "should replace the alias")
+	})
+
+	t.Run("Should replace aliases to omitted values", func(t *testing.T) {
+		y := yamlpath.MustNew([]string{"$.defaults: omit"})
+
+		out, err := y.Redact([]byte(testConfig))
+		assert.NoError(t, err)
+
+		var decoded struct {
+			Defaults map[string]interface{}
+			Prod     map[string]interface{}
+		}
+		assert.NoError(t, yaml.Unmarshal(out, &decoded), "should not leave dangling aliases")
+		assert.Nil(t, decoded.Defaults, "should omit the anchored value")
+		assert.Equal(t, "abc123", decoded.Prod["apiToken"], "should keep what the merge showed")
+	})
+
+	t.Run("Should redact everything not kept in allowlist mode", func(t *testing.T) {
+		y := yamlpath.MustNew([]string{"$.name: snapshot", "$.database.replicas: snapshot"}, yamlpath.Allowlist())
+
+		out, err := y.Redact([]byte("name: billing\nenabled: true\nport: 8080\nratio: 0.5\ncode: '0123'\n" +
+			"database: {host: db, replicas: [a, b]}\nempty: null\n---\nsecond: doc\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, "name: billing\nenabled: false\nport: 0\nratio: 0.0\ncode: 'NONSNAPSHOT'\n"+
+			"database: {host: NONSNAPSHOT, replicas: [a, b]}\nempty: null\n---\nsecond: NONSNAPSHOT\n", string(out),
+			"should redact every document by type")
+	})
+
+	t.Run("Should quote strings that would read as another type", func(t *testing.T) {
+		r := redact.New(redact.WithPlaceholder("true"))
+		y := yamlpath.MustNew([]string{"$.token: redact"}, yamlpath.WithRedactor(r))
+
+		out, err := y.Redact([]byte("token: abc\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, "token: \"true\"\n", string(out))
+	})
+
+	t.Run("Should stop at excessive aliasing", func(t *testing.T) {
+		doc := "a: &a [x, x, x, x, x, x, x, x, x]\n"
+		for _, level := range []string{"b", "c", "d", "e", "f", "g", "h", "i"} {
+			prev := string(rune(level[0] - 1))
+			doc += level + ": &" + level + " [*" + prev + strings.Repeat(", *"+prev, 8) + "]\n"
+		}
+		y := yamlpath.MustNew([]string{"$.i[0]: last4"})
+
+		start := time.Now()
+		out, err := y.Redact([]byte(doc))
+		assert.Nil(t, out)
+		assert.True(t, errors.Is(err, yamlpath.ErrExcessiveAliasing), "should refuse the document")
+		assert.Less(t, int64(time.Since(start)), int64(time.Second), "should stop early")
+	})
+
+	t.Run("Should report malformed documents without panicking", func(t *testing.T) {
+		y := yamlpath.MustNew([]string{"$.name: last4"})
+
+		assert.NotPanics(t, func() {
+			out, err := y.Redact([]byte("0: [:!00 \xef"))
+			assert.Nil(t, out)
+			assert.Error(t, err, "should refuse the document")
+		})
+	})
+
+	t.Run("Should report failing rules without output", func(t *testing.T) {
+		y := yamlpath.MustNew([]string{"$.name: truncate(x)"})
+
+		out, err := y.Redact([]byte(testConfig))
+		assert.Nil(t, out)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "$.name", "should report the location")
+	})
+}
+
+func TestRedactNode(t *testing.T) {
+	t.Run("Should redact node trees in place", func(t *testing.T) {
+		var node yaml.Node
+		assert.NoError(t, yaml.Unmarshal([]byte("users:\n  - name: alice\n    apiKey: k-1\n"), &node))
+
+		y := yamlpath.MustNew([]string{"$.users[*].apiKey: redact"})
+		assert.NoError(t, y.RedactNode(&node))
+
+		apiKey := node.Content[0].Content[1].Content[0].Content[3]
+		assert.Equal(t, redact.RedactStrConst, apiKey.Value)
+	})
+}
+
+func TestNew(t *testing.T) {
+	for _, rule := range []string{"password", "[: redact", "$.a: mask(", "$.a:"} {
+		_, err := yamlpath.New([]string{rule})
+		assert.Error(t, err, "should reject %q", rule)
+	}
+
+	_, err := yamlpath.New([]string{"*token*: nope"})
+	assert.True(t, errors.Is(err, redact.ErrUnknownRule), "should reject unknown rules")
+}